@@ -0,0 +1,79 @@
+package chunker
+
+import (
+	"fmt"
+	"strings"
+
+	"ragframework/internal/rag"
+)
+
+// Split breaks text into chunks of at most size words, each repeating the
+// last overlap words of the previous chunk.
+func Split(text string, size, overlap int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 || size <= 0 {
+		return nil
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	var chunks []string
+	for start := 0; ; start += size - overlap {
+		end := min(start+size, len(words))
+		chunks = append(chunks, strings.Join(words[start:end], " "))
+		if end == len(words) {
+			break
+		}
+	}
+	return chunks
+}
+
+// ParentChild splits a document into parent sections of parentSize words
+// and, within each, child chunks of childSize words for embedding. Every
+// child records its document, its position in the document and its parent;
+// parents carry their own ID under "id".
+func ParentChild(docID, text string, parentSize, childSize int) (parents, children []rag.ContextChunk) {
+	index := 0
+	for p, section := range Split(text, parentSize, 0) {
+		parentID := fmt.Sprintf("%s#p%d", docID, p)
+		parents = append(parents, rag.ContextChunk{
+			Text: section,
+			Metadata: map[string]interface{}{
+				"id":               parentID,
+				rag.MetaDocID:      docID,
+				rag.MetaChunkIndex: p,
+			},
+		})
+
+		for _, child := range Split(section, childSize, 0) {
+			children = append(children, rag.ContextChunk{
+				Text: child,
+				Metadata: map[string]interface{}{
+					rag.MetaDocID:      docID,
+					rag.MetaChunkIndex: index,
+					rag.MetaParentID:   parentID,
+				},
+			})
+			index++
+		}
+	}
+	return parents, children
+}
+
+// Document splits text into chunks of size words with overlap, recording
+// the document ID and each chunk's position so neighbouring chunks can be
+// fetched back at query time.
+func Document(docID, text string, size, overlap int) []rag.ContextChunk {
+	var chunks []rag.ContextChunk
+	for i, part := range Split(text, size, overlap) {
+		chunks = append(chunks, rag.ContextChunk{
+			Text: part,
+			Metadata: map[string]interface{}{
+				rag.MetaDocID:      docID,
+				rag.MetaChunkIndex: i,
+			},
+		})
+	}
+	return chunks
+}
@@ -0,0 +1,49 @@
+package embedder
+
+import (
+	"hash/fnv"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// SparseVector is a bag-of-words vector in the layout Qdrant expects for
+// sparse vectors: parallel slices of dimension indices and weights.
+type SparseVector struct {
+	Indices []uint32  `json:"indices"`
+	Values  []float32 `json:"values"`
+}
+
+// Tokenize lowercases text and splits it on anything that isn't a letter
+// or digit, so identifiers like "ERR-4012" become "err" and "4012".
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// SparseEmbed builds a term-frequency sparse vector for keyword matching.
+// Terms are hashed into the index space, so no vocabulary has to be shared
+// between ingestion and search; IDF weighting is left to the vector store
+// (Qdrant's "idf" modifier on the sparse vector config).
+func SparseEmbed(text string) SparseVector {
+	counts := make(map[uint32]float32)
+	for _, tok := range Tokenize(text) {
+		h := fnv.New32a()
+		h.Write([]byte(tok))
+		counts[h.Sum32()]++
+	}
+
+	vec := SparseVector{
+		Indices: make([]uint32, 0, len(counts)),
+		Values:  make([]float32, 0, len(counts)),
+	}
+	for idx := range counts {
+		vec.Indices = append(vec.Indices, idx)
+	}
+	sort.Slice(vec.Indices, func(i, j int) bool { return vec.Indices[i] < vec.Indices[j] })
+	for _, idx := range vec.Indices {
+		vec.Values = append(vec.Values, counts[idx])
+	}
+	return vec
+}
@@ -0,0 +1,301 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"ragframework/internal/rag"
+	"ragframework/internal/tokenizer"
+)
+
+// AnthropicGenerator implements the Generator interface using the
+// Anthropic Messages API
+type AnthropicGenerator struct {
+	APIKey string
+	Model  string // e.g., "claude-sonnet-4-5"
+
+	// BaseURL defaults to https://api.anthropic.com; point it at a proxy
+	// or a test server
+	BaseURL string
+
+	// Version is sent as the anthropic-version header (default "2023-06-01")
+	Version string
+
+	// DefaultMaxTokens is used when GenerateOptions.MaxTokens is 0, since
+	// the API requires a limit (default 1024)
+	DefaultMaxTokens int
+
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+func NewAnthropicGenerator(apiKey, model string) *AnthropicGenerator {
+	return &AnthropicGenerator{
+		APIKey:           apiKey,
+		Model:            model,
+		BaseURL:          "https://api.anthropic.com",
+		Version:          "2023-06-01",
+		DefaultMaxTokens: 1024,
+	}
+}
+
+type anthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model         string             `json:"model"`
+	MaxTokens     int                `json:"max_tokens"`
+	Messages      []anthropicMessage `json:"messages"`
+	System        string             `json:"system,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	Model   string `json:"model"`
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+	StopReason string         `json:"stop_reason"`
+	Usage      anthropicUsage `json:"usage"`
+}
+
+// anthropicEvent covers the streaming event payloads we read
+type anthropicEvent struct {
+	Type    string            `json:"type"`
+	Message anthropicResponse `json:"message"`
+	Delta   struct {
+		Type       string `json:"type"`
+		Text       string `json:"text"`
+		StopReason string `json:"stop_reason"`
+	} `json:"delta"`
+	Usage anthropicUsage `json:"usage"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Generate implements one-shot completion
+func (a *AnthropicGenerator) Generate(ctx context.Context, prompt string, opts rag.GenerateOptions) (*rag.GenerationResult, error) {
+	resp, err := a.post(ctx, a.request(prompt, opts, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("response decode failed: %w", err)
+	}
+
+	var text string
+	for _, block := range out.Content {
+		if block.Type == "text" {
+			text += block.Text
+		}
+	}
+	return &rag.GenerationResult{
+		Text:         text,
+		Usage:        anthropicTokenUsage(out.Usage),
+		FinishReason: anthropicFinishReason(out.StopReason),
+		Metadata:     map[string]interface{}{"model": out.Model},
+	}, nil
+}
+
+// GenerateStream implements streaming output over server-sent events. The
+// final chunk's Raw holds the model, stop_reason and usage gathered from
+// message_start and message_delta, in the shape of a non-streamed response.
+func (a *AnthropicGenerator) GenerateStream(ctx context.Context, prompt string, opts rag.GenerateOptions, onChunk func(rag.GenerationChunk)) error {
+	resp, err := a.post(ctx, a.request(prompt, opts, true))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var final anthropicResponse
+	var stopped bool
+	err = readSSE(resp.Body, func(_, data string) error {
+		var event anthropicEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("stream decode error: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			final = event.Message
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" && event.Delta.Text != "" {
+				onChunk(rag.GenerationChunk{Delta: event.Delta.Text, Raw: json.RawMessage(data)})
+			}
+		case "message_delta":
+			// Usage here is cumulative
+			final.StopReason = event.Delta.StopReason
+			if event.Usage.InputTokens > 0 {
+				final.Usage.InputTokens = event.Usage.InputTokens
+			}
+			final.Usage.OutputTokens = event.Usage.OutputTokens
+		case "message_stop":
+			raw, err := json.Marshal(final)
+			if err != nil {
+				return fmt.Errorf("stream encode error: %w", err)
+			}
+			onChunk(rag.GenerationChunk{
+				IsLast:       true,
+				Raw:          raw,
+				FinishReason: anthropicFinishReason(final.StopReason),
+				Metadata:     map[string]interface{}{"model": final.Model},
+			})
+			stopped = true
+			return io.EOF
+		case "error":
+			return &StatusError{
+				Provider:   "anthropic",
+				StatusCode: anthropicErrorStatus(event.Error.Type),
+				Type:       event.Error.Type,
+				Message:    event.Error.Message,
+			}
+		}
+		return ctx.Err()
+	})
+	if err == nil && !stopped {
+		return fmt.Errorf("stream ended before message_stop: %w", io.ErrUnexpectedEOF)
+	}
+	return err
+}
+
+// CountTokens estimates tokens; Anthropic publishes no local tokenizer
+func (a *AnthropicGenerator) CountTokens(text string) int {
+	return tokenizer.EstimateCount(text)
+}
+
+func (a *AnthropicGenerator) request(prompt string, opts rag.GenerateOptions, stream bool) anthropicRequest {
+	model := a.Model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	maxTokens := opts.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = a.DefaultMaxTokens
+	}
+	if maxTokens <= 0 {
+		maxTokens = 1024
+	}
+
+	req := anthropicRequest{
+		Model:         model,
+		MaxTokens:     maxTokens,
+		Messages:      []anthropicMessage{{Role: "user", Content: prompt}},
+		System:        opts.SystemPrompt,
+		StopSequences: opts.StopSequences,
+		Stream:        stream,
+	}
+	if opts.Temperature != nil {
+		// The API accepts 0.0–1.0
+		t := min(*opts.Temperature, 1)
+		req.Temperature = &t
+	}
+	return req
+}
+
+func (a *AnthropicGenerator) post(ctx context.Context, body anthropicRequest) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("request encoding failed: %w", err)
+	}
+
+	baseURL := a.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com"
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", baseURL+"/v1/messages", bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("request creation failed: %w", err)
+	}
+	version := a.Version
+	if version == "" {
+		version = "2023-06-01"
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", a.APIKey)
+	req.Header.Set("anthropic-version", version)
+	if body.Stream {
+		req.Header.Set("Accept", "text/event-stream")
+	}
+
+	client := a.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var apiErr anthropicEvent
+		raw, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, newStatusError("anthropic", resp, apiErr.Error.Type, apiErr.Error.Message)
+		}
+		return nil, newStatusError("anthropic", resp, "", string(bytes.TrimSpace(raw)))
+	}
+	return resp, nil
+}
+
+func anthropicTokenUsage(u anthropicUsage) *rag.TokenUsage {
+	return &rag.TokenUsage{
+		Input:  u.InputTokens,
+		Output: u.OutputTokens,
+		Total:  u.InputTokens + u.OutputTokens,
+	}
+}
+
+// anthropicErrorStatus maps the error type of an in-stream error event,
+// which arrives after a 200 response, to the status the API would have
+// returned for it
+func anthropicErrorStatus(errType string) int {
+	switch errType {
+	case "invalid_request_error":
+		return http.StatusBadRequest
+	case "authentication_error":
+		return http.StatusUnauthorized
+	case "permission_error":
+		return http.StatusForbidden
+	case "not_found_error":
+		return http.StatusNotFound
+	case "request_too_large":
+		return http.StatusRequestEntityTooLarge
+	case "rate_limit_error":
+		return http.StatusTooManyRequests
+	case "overloaded_error":
+		return 529
+	}
+	return http.StatusInternalServerError // api_error
+}
+
+// anthropicFinishReason maps stop_reason onto the GenerationResult values
+func anthropicFinishReason(reason string) string {
+	switch reason {
+	case "end_turn", "stop_sequence":
+		return "stop"
+	case "max_tokens":
+		return "length"
+	case "refusal":
+		return "content_filter"
+	}
+	return reason
+}
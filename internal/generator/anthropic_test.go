@@ -0,0 +1,224 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ragframework/internal/rag"
+)
+
+// anthropicServer stands in for the Messages API, recording the last
+// request body it received
+func anthropicServer(t *testing.T, got *anthropicRequest) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages" {
+			t.Errorf("path = %q, want /v1/messages", r.URL.Path)
+		}
+		if key := r.Header.Get("x-api-key"); key != "test-key" {
+			t.Errorf("x-api-key = %q", key)
+		}
+		if v := r.Header.Get("anthropic-version"); v != "2023-06-01" {
+			t.Errorf("anthropic-version = %q", v)
+		}
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+
+		if !got.Stream {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"model":"claude-test","content":[{"type":"text","text":"Hello"},{"type":"text","text":" world"}],`+
+				`"stop_reason":"max_tokens","usage":{"input_tokens":12,"output_tokens":5}}`)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		events := []string{
+			`{"type":"message_start","message":{"model":"claude-test","content":[],"usage":{"input_tokens":12,"output_tokens":1}}}`,
+			`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+			`{"type":"ping"}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hello"}}`,
+			`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":" world"}}`,
+			`{"type":"content_block_stop","index":0}`,
+			`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":5}}`,
+			`{"type":"message_stop"}`,
+		}
+		for _, e := range events {
+			var typ struct{ Type string }
+			json.Unmarshal([]byte(e), &typ)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typ.Type, e)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestAnthropic(srv *httptest.Server) *AnthropicGenerator {
+	g := NewAnthropicGenerator("test-key", "claude-test")
+	g.BaseURL = srv.URL
+	g.HTTPClient = srv.Client()
+	return g
+}
+
+func TestAnthropicGenerate(t *testing.T) {
+	var req anthropicRequest
+	g := newTestAnthropic(anthropicServer(t, &req))
+
+	result, err := g.Generate(context.Background(), "Say hello", rag.GenerateOptions{
+		MaxTokens:     5,
+		SystemPrompt:  "Be brief.",
+		StopSequences: []string{"\n\n"},
+	})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	if req.Model != "claude-test" || req.MaxTokens != 5 || req.System != "Be brief." || req.Stream {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if len(req.Messages) != 1 || req.Messages[0].Role != "user" || req.Messages[0].Content != "Say hello" {
+		t.Errorf("messages = %+v", req.Messages)
+	}
+	if len(req.StopSequences) != 1 || req.StopSequences[0] != "\n\n" {
+		t.Errorf("stop_sequences = %q", req.StopSequences)
+	}
+
+	if result.Text != "Hello world" {
+		t.Errorf("Text = %q", result.Text)
+	}
+	if result.FinishReason != "length" {
+		t.Errorf("FinishReason = %q, want length", result.FinishReason)
+	}
+	if u := result.Usage; u == nil || u.Input != 12 || u.Output != 5 || u.Total != 17 {
+		t.Errorf("Usage = %+v", u)
+	}
+	if result.Metadata["model"] != "claude-test" {
+		t.Errorf("model = %v", result.Metadata["model"])
+	}
+}
+
+func TestAnthropicGenerateStream(t *testing.T) {
+	var req anthropicRequest
+	g := newTestAnthropic(anthropicServer(t, &req))
+
+	var text strings.Builder
+	var chunks []rag.GenerationChunk
+	err := g.GenerateStream(context.Background(), "Say hello", rag.GenerateOptions{}, func(c rag.GenerationChunk) {
+		text.WriteString(c.Delta)
+		chunks = append(chunks, c)
+	})
+	if err != nil {
+		t.Fatalf("GenerateStream: %v", err)
+	}
+
+	if !req.Stream || req.MaxTokens != 1024 {
+		t.Errorf("unexpected request: %+v", req)
+	}
+	if text.String() != "Hello world" {
+		t.Errorf("streamed text = %q", text.String())
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(chunks))
+	}
+
+	last := chunks[len(chunks)-1]
+	if !last.IsLast {
+		t.Fatal("final chunk is not marked IsLast")
+	}
+	var final anthropicResponse
+	if err := json.Unmarshal(last.Raw, &final); err != nil {
+		t.Fatalf("decode final Raw: %v", err)
+	}
+	if final.Model != "claude-test" || final.StopReason != "end_turn" {
+		t.Errorf("final Raw = %s", last.Raw)
+	}
+	if final.Usage.InputTokens != 12 || final.Usage.OutputTokens != 5 {
+		t.Errorf("final usage = %+v", final.Usage)
+	}
+}
+
+func TestAnthropicStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`)
+	}))
+	defer srv.Close()
+
+	_, err := newTestAnthropic(srv).Generate(context.Background(), "hi", rag.GenerateOptions{})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) {
+		t.Fatalf("err = %v, want *StatusError", err)
+	}
+	if statusErr.StatusCode != 429 || statusErr.Type != "rate_limit_error" || statusErr.RetryAfter.Seconds() != 7 {
+		t.Errorf("StatusError = %+v", statusErr)
+	}
+	if !IsRetryable(err) {
+		t.Error("429 should be retryable")
+	}
+}
+
+func TestAnthropicZeroTemperature(t *testing.T) {
+	var req anthropicRequest
+	g := newTestAnthropic(anthropicServer(t, &req))
+
+	zero := 0.0
+	if _, err := g.Generate(context.Background(), "hi", rag.GenerateOptions{Temperature: &zero}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if req.Temperature == nil || *req.Temperature != 0 {
+		t.Errorf("temperature = %v, want 0", req.Temperature)
+	}
+
+	req = anthropicRequest{}
+	if _, err := g.Generate(context.Background(), "hi", rag.GenerateOptions{}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if req.Temperature != nil {
+		t.Errorf("temperature = %v, want unset", *req.Temperature)
+	}
+}
+
+func TestAnthropicStreamError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer srv.Close()
+
+	err := newTestAnthropic(srv).GenerateStream(context.Background(), "hi", rag.GenerateOptions{}, func(rag.GenerationChunk) {})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != 529 || statusErr.Type != "overloaded_error" {
+		t.Fatalf("err = %v, want a 529 overloaded_error StatusError", err)
+	}
+	if !IsRetryable(err) {
+		t.Error("overloaded_error should be retryable")
+	}
+}
+
+// A body that ends before message_stop is a truncated response
+func TestAnthropicStreamTruncated(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n")
+	}))
+	defer srv.Close()
+
+	var chunks []rag.GenerationChunk
+	err := newTestAnthropic(srv).GenerateStream(context.Background(), "hi", rag.GenerateOptions{}, func(c rag.GenerationChunk) {
+		chunks = append(chunks, c)
+	})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("err = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if len(chunks) != 1 || chunks[0].IsLast {
+		t.Errorf("chunks = %+v, want one non-final chunk", chunks)
+	}
+}
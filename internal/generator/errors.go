@@ -0,0 +1,128 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// StatusError is a provider API error response
+type StatusError struct {
+	Provider   string // e.g. "anthropic"
+	StatusCode int
+	Type       string // Provider error type or status, if given
+	Message    string
+
+	// RetryAfter is the server's requested wait from the Retry-After
+	// header, or 0 if none was sent
+	RetryAfter time.Duration
+
+	// Err is the client library's own error, if the StatusError was
+	// built from one
+	Err error
+}
+
+func (e *StatusError) Error() string {
+	if e.Type != "" {
+		return fmt.Sprintf("%s API error (%d %s): %s", e.Provider, e.StatusCode, e.Type, e.Message)
+	}
+	return fmt.Sprintf("%s API error (%d): %s", e.Provider, e.StatusCode, e.Message)
+}
+
+func (e *StatusError) Unwrap() error {
+	return e.Err
+}
+
+// ProviderError is an error a provider reports in the body of a
+// successful response, such as a failure in Ollama's model runner. It has
+// no HTTP status and is not retryable.
+type ProviderError struct {
+	Provider string
+	Message  string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s error: %s", e.Provider, e.Message)
+}
+
+// newStatusError builds a StatusError from a failed response
+func newStatusError(provider string, resp *http.Response, errType, message string) *StatusError {
+	return &StatusError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		Type:       errType,
+		Message:    message,
+		RetryAfter: headerRetryAfter(resp.Header),
+	}
+}
+
+// headerRetryAfter reads Retry-After, preferring the millisecond
+// retry-after-ms that OpenAI and Azure also send
+func headerRetryAfter(h http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(h.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	return parseRetryAfter(h.Get("Retry-After"))
+}
+
+// parseRetryAfter reads delay-seconds or an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// IsRetryable reports whether err is worth retrying: rate limits (429),
+// server errors (5xx, including Anthropic's 529 "overloaded"), request
+// timeouts (408) and network failures. Context cancellation is not.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if code := statusCode(err); code != 0 {
+		return code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// statusCode extracts the HTTP status from our own and go-openai's errors
+func statusCode(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode
+	}
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.HTTPStatusCode
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.HTTPStatusCode
+	}
+	return 0
+}
+
+// retryAfter returns the server-requested delay carried by err, if any
+func retryAfter(err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"ragframework/internal/jsonschema"
+	"ragframework/internal/rag"
+)
+
+// Extract asks gen for a T: the JSON Schema derived from T (see
+// jsonschema.Reflect) is sent as the ResponseFormat, the output is
+// validated with re-prompting, and the result is decoded into T.
+// OpenAI structured outputs need T to be a struct.
+//
+//	type Invoice struct {
+//		Number string  `json:"number" jsonschema:"description=Invoice number"`
+//		Total  float64 `json:"total" jsonschema:"minimum=0"`
+//	}
+//	invoice, err := generator.Extract[Invoice](ctx, gen, prompt, rag.GenerateOptions{})
+func Extract[T any](ctx context.Context, gen rag.Generator, prompt string, opts rag.GenerateOptions) (T, error) {
+	var out T
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	options := map[string]string{}
+	if opts.ResponseFormat != nil {
+		for k, v := range opts.ResponseFormat.Options {
+			options[k] = v
+		}
+	}
+	if options["name"] == "" && t.Name() != "" {
+		options["name"] = t.Name()
+	}
+	opts.ResponseFormat = &rag.ResponseFormat{
+		Type:    "json",
+		Schema:  jsonschema.ReflectType(t),
+		Options: options,
+	}
+
+	if _, ok := gen.(*ValidatingGenerator); !ok {
+		gen = NewValidatingGenerator(gen, 0)
+	}
+	result, err := gen.Generate(ctx, prompt, opts)
+	if err != nil {
+		return out, fmt.Errorf("extraction failed: %w", err)
+	}
+	if err := json.Unmarshal([]byte(result.Text), &out); err != nil {
+		return out, fmt.Errorf("failed to decode extracted %s: %w", t, err)
+	}
+	return out, nil
+}
@@ -0,0 +1,166 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"ragframework/internal/rag"
+)
+
+// FallbackGenerator tries Generators in order, moving on when one fails
+// with an error ShouldFallback accepts or finishes with "content_filter".
+// The answering generator's model is recorded in Metadata["model"] and its
+// position in Metadata["fallback_index"].
+//
+// Streams fall back only until the first token has been delivered; after
+// that an error is returned as-is. The final chunk carries the same
+// metadata.
+type FallbackGenerator struct {
+	Generators []rag.Generator
+
+	// ShouldFallback decides which errors move on to the next generator
+	// (default IsRetryable)
+	ShouldFallback func(error) bool
+}
+
+func NewFallbackGenerator(generators ...rag.Generator) *FallbackGenerator {
+	return &FallbackGenerator{
+		Generators:     generators,
+		ShouldFallback: IsRetryable,
+	}
+}
+
+func (f *FallbackGenerator) Generate(ctx context.Context, prompt string, opts rag.GenerateOptions) (*rag.GenerationResult, error) {
+	var errs []error
+	for i, g := range f.Generators {
+		last := i == len(f.Generators)-1
+
+		result, err := g.Generate(ctx, prompt, opts)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", modelName(g), err))
+			if last || ctx.Err() != nil || !f.shouldFallback(err) {
+				return nil, f.failure(errs)
+			}
+			continue
+		}
+		if result.FinishReason == "content_filter" && !last {
+			errs = append(errs, fmt.Errorf("%s: response blocked by content filter", modelName(g)))
+			continue
+		}
+
+		result.Metadata = answeredBy(result.Metadata, g, i)
+		return result, nil
+	}
+	return nil, f.failure(errs)
+}
+
+func (f *FallbackGenerator) GenerateStream(ctx context.Context, prompt string, opts rag.GenerateOptions, onChunk func(rag.GenerationChunk)) error {
+	var errs []error
+	for i, g := range f.Generators {
+		last := i == len(f.Generators)-1
+
+		// Chunks without text are held back until the first token, so a
+		// generator that fails or is filtered before producing one leaves
+		// no trace
+		started := false
+		var pending []rag.GenerationChunk
+		err := g.GenerateStream(ctx, prompt, opts, func(chunk rag.GenerationChunk) {
+			if chunk.IsLast {
+				chunk.Metadata = answeredBy(chunk.Metadata, g, i)
+			}
+			if !started && chunk.Delta == "" {
+				pending = append(pending, chunk)
+				return
+			}
+			if !started {
+				started = true
+				for _, p := range pending {
+					onChunk(p)
+				}
+				pending = nil
+			}
+			onChunk(chunk)
+		})
+		if err == nil {
+			if !started && !last && len(pending) > 0 && pending[len(pending)-1].FinishReason == "content_filter" {
+				errs = append(errs, fmt.Errorf("%s: response blocked by content filter", modelName(g)))
+				continue
+			}
+			for _, p := range pending {
+				onChunk(p)
+			}
+			return nil
+		}
+
+		errs = append(errs, fmt.Errorf("%s: %w", modelName(g), err))
+		if started || last || ctx.Err() != nil || !f.shouldFallback(err) {
+			return f.failure(errs)
+		}
+	}
+	return f.failure(errs)
+}
+
+// CountTokens uses the primary generator, which most prompts are sized for
+func (f *FallbackGenerator) CountTokens(text string) int {
+	if len(f.Generators) == 0 {
+		return 0
+	}
+	return f.Generators[0].CountTokens(text)
+}
+
+func (f *FallbackGenerator) shouldFallback(err error) bool {
+	if f.ShouldFallback != nil {
+		return f.ShouldFallback(err)
+	}
+	return IsRetryable(err)
+}
+
+// failure returns the only error as-is, or all of them when several
+// generators were tried
+func (f *FallbackGenerator) failure(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return fmt.Errorf("no generators configured")
+	case 1:
+		return errs[0]
+	}
+	return fmt.Errorf("all %d generators failed: %w", len(errs), errors.Join(errs...))
+}
+
+// answeredBy copies metadata, recording the answering generator's model
+// (unless the provider reported one) and its position in the chain
+func answeredBy(metadata map[string]interface{}, g rag.Generator, index int) map[string]interface{} {
+	out := make(map[string]interface{}, len(metadata)+2)
+	for k, v := range metadata {
+		out[k] = v
+	}
+	if model, _ := out["model"].(string); model == "" {
+		out["model"] = modelName(g)
+	}
+	out["fallback_index"] = index
+	return out
+}
+
+// modelName identifies a generator for metadata and error messages
+func modelName(g rag.Generator) string {
+	switch g := g.(type) {
+	case *OpenAIGenerator:
+		return g.model
+	case *AnthropicGenerator:
+		return g.Model
+	case *GeminiGenerator:
+		return g.Model
+	case *MistralGenerator:
+		return g.Model
+	case *RetryGenerator:
+		return modelName(g.Generator)
+	case *TimeoutGenerator:
+		return modelName(g.Generator)
+	case *RateLimitGenerator:
+		return modelName(g.Generator)
+	case *ValidatingGenerator:
+		return modelName(g.Generator)
+	}
+	return fmt.Sprintf("%T", g)
+}
@@ -0,0 +1,138 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"ragframework/internal/rag"
+)
+
+// collect streams from g and returns the text and final chunk
+func collect(t *testing.T, g rag.Generator) (string, rag.GenerationChunk, error) {
+	t.Helper()
+	var text strings.Builder
+	var last rag.GenerationChunk
+	err := g.GenerateStream(context.Background(), "hi", rag.GenerateOptions{}, func(c rag.GenerationChunk) {
+		text.WriteString(c.Delta)
+		if c.IsLast {
+			last = c
+		}
+	})
+	return text.String(), last, err
+}
+
+func TestFallbackBeforeFirstToken(t *testing.T) {
+	primary := &stubGenerator{errs: []error{statusErr(http.StatusServiceUnavailable)}}
+	backup := &stubGenerator{deltas: []string{"from ", "backup"}}
+	f := NewFallbackGenerator(primary, backup)
+
+	text, last, err := collect(t, f)
+	if err != nil {
+		t.Fatalf("GenerateStream: %v", err)
+	}
+	if text != "from backup" || last.Metadata["fallback_index"] != 1 {
+		t.Errorf("text = %q, final chunk = %+v", text, last)
+	}
+
+	result, err := f.Generate(context.Background(), "hi", rag.GenerateOptions{})
+	if err != nil || result.Metadata["fallback_index"] != 0 {
+		t.Errorf("Generate = %+v, %v; want the recovered primary", result, err)
+	}
+}
+
+// Once a token has been delivered, switching generators would splice two
+// answers together
+func TestFallbackAfterFirstToken(t *testing.T) {
+	down := statusErr(http.StatusServiceUnavailable)
+	primary := &stubGenerator{errs: []error{down}, deltas: []string{"Hel"}}
+	backup := &stubGenerator{deltas: []string{"backup"}}
+
+	text, _, err := collect(t, NewFallbackGenerator(primary, backup))
+	if !errors.Is(err, down) {
+		t.Fatalf("err = %v, want the primary's error", err)
+	}
+	if text != "Hel" || backup.calls != 0 {
+		t.Errorf("text = %q, backup calls = %d", text, backup.calls)
+	}
+}
+
+func TestFallbackContentFilter(t *testing.T) {
+	primary := &stubGenerator{finish: "content_filter"}
+	backup := &stubGenerator{deltas: []string{"answer"}}
+	f := NewFallbackGenerator(primary, backup)
+
+	text, last, err := collect(t, f)
+	if err != nil {
+		t.Fatalf("GenerateStream: %v", err)
+	}
+	if text != "answer" || last.FinishReason != "stop" || last.Metadata["fallback_index"] != 1 {
+		t.Errorf("text = %q, final chunk = %+v", text, last)
+	}
+
+	result, err := f.Generate(context.Background(), "hi", rag.GenerateOptions{})
+	if err != nil || result.Text != "answer" {
+		t.Errorf("Generate = %+v, %v", result, err)
+	}
+
+	// The last generator's filtered answer is returned as it is
+	_, last, err = collect(t, NewFallbackGenerator(primary))
+	if err != nil || last.FinishReason != "content_filter" {
+		t.Errorf("final chunk = %+v, err = %v", last, err)
+	}
+}
+
+func TestFallbackNonRetryable(t *testing.T) {
+	bad := statusErr(http.StatusBadRequest)
+	primary := &stubGenerator{errs: []error{bad, bad}}
+	backup := &stubGenerator{deltas: []string{"backup"}}
+	f := NewFallbackGenerator(primary, backup)
+
+	if _, _, err := collect(t, f); !errors.Is(err, bad) {
+		t.Errorf("stream err = %v, want the primary's error", err)
+	}
+	if _, err := f.Generate(context.Background(), "hi", rag.GenerateOptions{}); !errors.Is(err, bad) {
+		t.Errorf("Generate err = %v, want the primary's error", err)
+	}
+	if backup.calls != 0 {
+		t.Errorf("backup called %d times", backup.calls)
+	}
+}
+
+func TestFallbackAllFail(t *testing.T) {
+	first, second := statusErr(http.StatusServiceUnavailable), statusErr(http.StatusTooManyRequests)
+	f := NewFallbackGenerator(&stubGenerator{errs: []error{first}}, &stubGenerator{errs: []error{second}})
+
+	_, err := f.Generate(context.Background(), "hi", rag.GenerateOptions{})
+	if !errors.Is(err, first) || !errors.Is(err, second) {
+		t.Errorf("err = %v, want both errors", err)
+	}
+}
+
+// The provider's reported model is kept; fallback_index records the
+// position in the chain
+func TestFallbackAnsweredBy(t *testing.T) {
+	primary := &stubGenerator{errs: []error{statusErr(http.StatusServiceUnavailable)}}
+	backup := &stubGenerator{deltas: []string{"ok"}, model: "backup-model-2024"}
+
+	_, last, err := collect(t, NewFallbackGenerator(primary, backup))
+	if err != nil {
+		t.Fatalf("GenerateStream: %v", err)
+	}
+	if last.Metadata["model"] != "backup-model-2024" || last.Metadata["fallback_index"] != 1 {
+		t.Errorf("final chunk metadata = %v", last.Metadata)
+	}
+
+	// Without a reported model, the generator's configured name is used
+	primary.calls = 0
+	backup.model = ""
+	result, err := NewFallbackGenerator(primary, backup).Generate(context.Background(), "hi", rag.GenerateOptions{})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if result.Metadata["model"] != modelName(backup) || result.Metadata["fallback_index"] != 1 {
+		t.Errorf("metadata = %v", result.Metadata)
+	}
+}
@@ -0,0 +1,265 @@
+package generator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"ragframework/internal/rag"
+	"ragframework/internal/tokenizer"
+)
+
+// GeminiGenerator implements the Generator interface using the Google
+// Gemini API (generateContent / streamGenerateContent)
+type GeminiGenerator struct {
+	APIKey string
+	Model  string // e.g., "gemini-2.5-flash"
+
+	// BaseURL defaults to https://generativelanguage.googleapis.com/v1beta
+	BaseURL string
+
+	// HTTPClient defaults to http.DefaultClient
+	HTTPClient *http.Client
+}
+
+func NewGeminiGenerator(apiKey, model string) *GeminiGenerator {
+	return &GeminiGenerator{
+		APIKey:  apiKey,
+		Model:   model,
+		BaseURL: "https://generativelanguage.googleapis.com/v1beta",
+	}
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature        *float64        `json:"temperature,omitempty"`
+	MaxOutputTokens    int             `json:"maxOutputTokens,omitempty"`
+	StopSequences      []string        `json:"stopSequences,omitempty"`
+	ResponseMimeType   string          `json:"responseMimeType,omitempty"`
+	ResponseJSONSchema json.RawMessage `json:"responseJsonSchema,omitempty"`
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent        `json:"contents"`
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+	ModelVersion string `json:"modelVersion"`
+}
+
+// text joins the first candidate's text parts
+func (r *geminiResponse) text() string {
+	if len(r.Candidates) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, p := range r.Candidates[0].Content.Parts {
+		sb.WriteString(p.Text)
+	}
+	return sb.String()
+}
+
+// finishReason maps the candidate's finishReason, or a blocked prompt,
+// onto the GenerationResult values
+func (r *geminiResponse) finishReason() string {
+	if r.PromptFeedback.BlockReason != "" {
+		return "content_filter"
+	}
+	if len(r.Candidates) == 0 {
+		return ""
+	}
+	switch reason := r.Candidates[0].FinishReason; reason {
+	case "STOP":
+		return "stop"
+	case "MAX_TOKENS":
+		return "length"
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
+		return "content_filter"
+	default:
+		return strings.ToLower(reason)
+	}
+}
+
+func (r *geminiResponse) usage() *rag.TokenUsage {
+	if r.UsageMetadata == nil {
+		return nil
+	}
+	return &rag.TokenUsage{
+		Input:  r.UsageMetadata.PromptTokenCount,
+		Output: r.UsageMetadata.CandidatesTokenCount,
+		Total:  r.UsageMetadata.TotalTokenCount,
+	}
+}
+
+// Generate implements one-shot completion
+func (g *GeminiGenerator) Generate(ctx context.Context, prompt string, opts rag.GenerateOptions) (*rag.GenerationResult, error) {
+	resp, err := g.post(ctx, "generateContent", prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var out geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("response decode failed: %w", err)
+	}
+	return &rag.GenerationResult{
+		Text:         out.text(),
+		Usage:        out.usage(),
+		FinishReason: out.finishReason(),
+		Metadata:     map[string]interface{}{"model": out.ModelVersion},
+	}, nil
+}
+
+// GenerateStream implements streaming output over server-sent events;
+// each event carries a partial response
+func (g *GeminiGenerator) GenerateStream(ctx context.Context, prompt string, opts rag.GenerateOptions, onChunk func(rag.GenerationChunk)) error {
+	resp, err := g.post(ctx, "streamGenerateContent", prompt, opts)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var last json.RawMessage
+	var final geminiResponse
+	err = readSSE(resp.Body, func(_, data string) error {
+		var out geminiResponse
+		if err := json.Unmarshal([]byte(data), &out); err != nil {
+			return fmt.Errorf("stream decode error: %w", err)
+		}
+		last, final = json.RawMessage(data), out
+		if text := out.text(); text != "" {
+			onChunk(rag.GenerationChunk{Delta: text, Raw: last})
+		}
+		return ctx.Err()
+	})
+	if err != nil {
+		return err
+	}
+	// Only the last event of a complete stream carries a finishReason
+	if final.finishReason() == "" {
+		return fmt.Errorf("stream ended without a finishReason: %w", io.ErrUnexpectedEOF)
+	}
+	onChunk(rag.GenerationChunk{
+		IsLast:       true,
+		Raw:          last,
+		FinishReason: final.finishReason(),
+		Metadata:     map[string]interface{}{"model": final.ModelVersion},
+	})
+	return nil
+}
+
+// CountTokens estimates tokens; Gemini's tokenizer isn't published for
+// local use
+func (g *GeminiGenerator) CountTokens(text string) int {
+	return tokenizer.EstimateCount(text)
+}
+
+func (g *GeminiGenerator) request(prompt string, opts rag.GenerateOptions) (geminiRequest, error) {
+	req := geminiRequest{
+		Contents: []geminiContent{{Role: "user", Parts: []geminiPart{{Text: prompt}}}},
+		GenerationConfig: geminiGenerationConfig{
+			MaxOutputTokens: opts.MaxTokens,
+			StopSequences:   opts.StopSequences,
+		},
+	}
+	if opts.SystemPrompt != "" {
+		req.SystemInstruction = &geminiContent{Parts: []geminiPart{{Text: opts.SystemPrompt}}}
+	}
+	if opts.Temperature != nil {
+		t := *opts.Temperature
+		req.GenerationConfig.Temperature = &t
+	}
+
+	isJSON, schema, err := jsonResponse(opts)
+	if err != nil {
+		return req, err
+	}
+	if isJSON {
+		req.GenerationConfig.ResponseMimeType = "application/json"
+		req.GenerationConfig.ResponseJSONSchema = schema
+	}
+	return req, nil
+}
+
+func (g *GeminiGenerator) post(ctx context.Context, method, prompt string, opts rag.GenerateOptions) (*http.Response, error) {
+	body, err := g.request(prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("request encoding failed: %w", err)
+	}
+
+	model := g.Model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	baseURL := g.BaseURL
+	if baseURL == "" {
+		baseURL = "https://generativelanguage.googleapis.com/v1beta"
+	}
+	endpoint := fmt.Sprintf("%s/models/%s:%s", baseURL, url.PathEscape(model), method)
+	if method == "streamGenerateContent" {
+		endpoint += "?alt=sse"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("request creation failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-goog-api-key", g.APIKey)
+
+	client := g.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Error struct {
+				Message string `json:"message"`
+				Status  string `json:"status"`
+			} `json:"error"`
+		}
+		raw, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error.Message != "" {
+			return nil, newStatusError("gemini", resp, apiErr.Error.Status, apiErr.Error.Message)
+		}
+		return nil, newStatusError("gemini", resp, "", string(bytes.TrimSpace(raw)))
+	}
+	return resp, nil
+}
@@ -0,0 +1,93 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ragframework/internal/rag"
+)
+
+// geminiStreamServer replies to streamGenerateContent with the given
+// events
+func geminiStreamServer(t *testing.T, events ...string) *GeminiGenerator {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-test:streamGenerateContent" || r.URL.Query().Get("alt") != "sse" {
+			t.Errorf("url = %s", r.URL)
+		}
+		if key := r.Header.Get("x-goog-api-key"); key != "test-key" {
+			t.Errorf("x-goog-api-key = %q", key)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, e := range events {
+			fmt.Fprintf(w, "data: %s\n\n", e)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	g := NewGeminiGenerator("test-key", "gemini-test")
+	g.BaseURL = srv.URL
+	g.HTTPClient = srv.Client()
+	return g
+}
+
+func TestGeminiGenerateStream(t *testing.T) {
+	g := geminiStreamServer(t,
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":"Hello"}]}}],"modelVersion":"gemini-test-001"}`,
+		`{"candidates":[{"content":{"role":"model","parts":[{"text":" world"}]},"finishReason":"STOP"}],`+
+			`"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":2,"totalTokenCount":6},"modelVersion":"gemini-test-001"}`,
+	)
+
+	var text strings.Builder
+	var last rag.GenerationChunk
+	err := g.GenerateStream(context.Background(), "Say hello", rag.GenerateOptions{}, func(c rag.GenerationChunk) {
+		text.WriteString(c.Delta)
+		last = c
+	})
+	if err != nil {
+		t.Fatalf("GenerateStream: %v", err)
+	}
+	if text.String() != "Hello world" {
+		t.Errorf("streamed text = %q", text.String())
+	}
+	if !last.IsLast || last.FinishReason != "stop" || last.Metadata["model"] != "gemini-test-001" {
+		t.Errorf("final chunk = %+v", last)
+	}
+}
+
+// A body that ends before any event carries a finishReason is a truncated
+// response
+func TestGeminiStreamTruncated(t *testing.T) {
+	g := geminiStreamServer(t, `{"candidates":[{"content":{"role":"model","parts":[{"text":"Hel"}]}}]}`)
+
+	var chunks []rag.GenerationChunk
+	err := g.GenerateStream(context.Background(), "hi", rag.GenerateOptions{}, func(c rag.GenerationChunk) {
+		chunks = append(chunks, c)
+	})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("err = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if len(chunks) != 1 || chunks[0].IsLast {
+		t.Errorf("chunks = %+v, want one non-final chunk", chunks)
+	}
+}
+
+// A blocked prompt ends the stream with no candidates at all
+func TestGeminiStreamBlockedPrompt(t *testing.T) {
+	g := geminiStreamServer(t, `{"promptFeedback":{"blockReason":"SAFETY"}}`)
+
+	var last rag.GenerationChunk
+	err := g.GenerateStream(context.Background(), "hi", rag.GenerateOptions{}, func(c rag.GenerationChunk) { last = c })
+	if err != nil {
+		t.Fatalf("GenerateStream: %v", err)
+	}
+	if !last.IsLast || last.FinishReason != "content_filter" {
+		t.Errorf("final chunk = %+v", last)
+	}
+}
@@ -9,113 +9,216 @@ import (
 	"net/http"
 
 	"ragframework/internal/rag"
+	"ragframework/internal/tokenizer"
 )
 
 // MistralGenerator implements the Generator interface using Ollama
 type MistralGenerator struct {
 	Model string
 	Host  string // e.g., "http://localhost:11434"
+
+	// Tokenizer counts tokens for CountTokens; nil falls back to an estimate
+	Tokenizer tokenizer.Tokenizer
+
+	// TokenizerErr records why the constructor couldn't load a tokenizer
+	// for the model, e.g. a missing file in RAG_TOKENIZER_DIR
+	TokenizerErr error
 }
 
 func NewMistralGenerator(host, model string) *MistralGenerator {
-	return &MistralGenerator{
+	m := &MistralGenerator{
 		Host:  host,
 		Model: model,
 	}
+	m.Tokenizer, m.TokenizerErr = tokenizer.ForModel(model)
+	return m
 }
 
-// Generate implements one-shot completion
-func (m *MistralGenerator) Generate(ctx context.Context, prompt string, opts rag.GenerateOptions) (*rag.GenerationResult, error) {
-	reqBody := map[string]interface{}{
-		"model":      m.Model,
-		"prompt":     prompt,
-		"temperature": opts.Temperature,
-		"stop":        opts.StopSequences,
-		"options": map[string]interface{}{
-			"num_predict": opts.MaxTokens,
-		},
+// ollamaChatResponse is one /api/chat response, or one line of a stream
+type ollamaChatResponse struct {
+	Model   string `json:"model"`
+	Message struct {
+		Role    string `json:"role"`
+		Content string `json:"content"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	DoneReason      string `json:"done_reason"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+	Error           string `json:"error"`
+}
+
+func (r *ollamaChatResponse) usage() *rag.TokenUsage {
+	return &rag.TokenUsage{
+		Input:  r.PromptEvalCount,
+		Output: r.EvalCount,
+		Total:  r.PromptEvalCount + r.EvalCount,
 	}
-	body, _ := json.Marshal(reqBody)
+}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", m.Host+"/api/generate", bytes.NewBuffer(body))
+// Generate implements one-shot completion
+func (m *MistralGenerator) Generate(ctx context.Context, prompt string, opts rag.GenerateOptions) (*rag.GenerationResult, error) {
+	reqBody, err := m.chatBody(prompt, opts, false)
 	if err != nil {
-		return nil, fmt.Errorf("request creation failed: %w", err)
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := m.post(ctx, reqBody)
 	if err != nil {
-		return nil, fmt.Errorf("request failed: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	var fullOutput string
-	decoder := json.NewDecoder(resp.Body)
-	for decoder.More() {
-		var chunk map[string]interface{}
-		if err := decoder.Decode(&chunk); err != nil {
-			return nil, fmt.Errorf("stream decode failed: %w", err)
-		}
-		if part, ok := chunk["response"].(string); ok {
-			fullOutput += part
-		}
+	var out ollamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("response decode failed: %w", err)
+	}
+	if out.Error != "" {
+		return nil, &ProviderError{Provider: "ollama", Message: out.Error}
 	}
 
 	return &rag.GenerationResult{
-		Text: fullOutput,
+		Text:         out.Message.Content,
+		Usage:        out.usage(),
+		FinishReason: out.DoneReason,
+		Metadata:     map[string]interface{}{"model": out.Model},
 	}, nil
 }
 
 // GenerateStream implements streaming output
 func (m *MistralGenerator) GenerateStream(ctx context.Context, prompt string, opts rag.GenerateOptions, onChunk func(rag.GenerationChunk)) error {
-	reqBody := map[string]interface{}{
-		"model":      m.Model,
-		"prompt":     prompt,
-		"stream":     true,
-		"temperature": opts.Temperature,
-		"stop":        opts.StopSequences,
-	}
-	body, _ := json.Marshal(reqBody)
-
-	req, err := http.NewRequestWithContext(ctx, "POST", m.Host+"/api/generate", bytes.NewBuffer(body))
+	reqBody, err := m.chatBody(prompt, opts, true)
 	if err != nil {
-		return fmt.Errorf("stream request creation failed: %w", err)
+		return err
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := m.post(ctx, reqBody)
 	if err != nil {
-		return fmt.Errorf("stream request failed: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
 	decoder := json.NewDecoder(resp.Body)
 	for {
-		var chunk map[string]interface{}
-		if err := decoder.Decode(&chunk); err != nil {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
 			if err == io.EOF {
-				break
+				return fmt.Errorf("stream ended before done: %w", io.ErrUnexpectedEOF)
 			}
 			return fmt.Errorf("stream decode error: %w", err)
 		}
+		var chunk ollamaChatResponse
+		if err := json.Unmarshal(raw, &chunk); err != nil {
+			return fmt.Errorf("stream decode error: %w", err)
+		}
+		if chunk.Error != "" {
+			return &ProviderError{Provider: "ollama", Message: chunk.Error}
+		}
+
+		out := rag.GenerationChunk{
+			Delta:  chunk.Message.Content,
+			IsLast: chunk.Done,
+			Raw:    raw, // The final chunk carries done_reason and eval counts
+		}
+		if chunk.Done {
+			out.FinishReason = chunk.DoneReason
+			out.Metadata = map[string]interface{}{"model": chunk.Model}
+		}
+		onChunk(out)
+
+		if chunk.Done {
+			return nil
+		}
+	}
+}
+
+// chatBody builds an /api/chat request. Sampling settings go in "options",
+// the only place Ollama reads them; zero values keep the model defaults.
+func (m *MistralGenerator) chatBody(prompt string, opts rag.GenerateOptions, stream bool) (map[string]interface{}, error) {
+	model := m.Model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+
+	var messages []map[string]string
+	if opts.SystemPrompt != "" {
+		messages = append(messages, map[string]string{"role": "system", "content": opts.SystemPrompt})
+	}
+	messages = append(messages, map[string]string{"role": "user", "content": prompt})
+
+	options := map[string]interface{}{}
+	if opts.Temperature != nil {
+		options["temperature"] = *opts.Temperature
+	}
+	if opts.MaxTokens > 0 {
+		options["num_predict"] = opts.MaxTokens
+	}
+	if len(opts.StopSequences) > 0 {
+		options["stop"] = opts.StopSequences
+	}
+
+	reqBody := map[string]interface{}{
+		"model":    model,
+		"messages": messages,
+		"stream":   stream,
+		"options":  options,
+	}
+	if err := setOllamaFormat(reqBody, opts); err != nil {
+		return nil, err
+	}
+	return reqBody, nil
+}
 
-		part, _ := chunk["response"].(string)
-		done, _ := chunk["done"].(bool)
+// post sends a chat request and turns error statuses into a StatusError
+// carrying Ollama's message
+func (m *MistralGenerator) post(ctx context.Context, reqBody map[string]interface{}) (*http.Response, error) {
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("request encoding failed: %w", err)
+	}
 
-		onChunk(rag.GenerationChunk{
-			Delta:  part,
-			IsLast: done,
-			Raw:    nil, // Ollama doesn't give raw response by default
-		})
+	req, err := http.NewRequestWithContext(ctx, "POST", m.Host+"/api/chat", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("request creation failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
 
-		if done {
-			break
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		raw, _ := io.ReadAll(resp.Body)
+		var apiErr struct {
+			Error string `json:"error"`
 		}
+		if json.Unmarshal(raw, &apiErr) == nil && apiErr.Error != "" {
+			return nil, newStatusError("ollama", resp, "", apiErr.Error)
+		}
+		return nil, newStatusError("ollama", resp, "", string(bytes.TrimSpace(raw)))
+	}
+	return resp, nil
+}
+
+// setOllamaFormat maps a "json" ResponseFormat to Ollama's "format": the
+// schema itself for structured outputs, or "json" for any JSON value
+func setOllamaFormat(reqBody map[string]interface{}, opts rag.GenerateOptions) error {
+	isJSON, schema, err := jsonResponse(opts)
+	if err != nil || !isJSON {
+		return err
+	}
+	if schema == nil {
+		reqBody["format"] = "json"
+	} else {
+		reqBody["format"] = schema
 	}
 	return nil
 }
 
-// CountTokens is a placeholder (you can implement real tokenizer later)
+// CountTokens uses the model's tokenizer.json when one is available, and a
+// rough estimate otherwise (Mistral ≈ 4 chars per token)
 func (m *MistralGenerator) CountTokens(text string) int {
-	return len([]rune(text)) / 4 // Rough estimate (Mistral ≈ 4 chars per token)
+	if m.Tokenizer != nil {
+		return m.Tokenizer.Count(text)
+	}
+	return tokenizer.EstimateCount(text)
 }
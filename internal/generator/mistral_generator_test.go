@@ -0,0 +1,98 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ragframework/internal/rag"
+)
+
+// ollamaServer answers /api/chat with the given NDJSON lines
+func ollamaServer(t *testing.T, lines ...string) *MistralGenerator {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %q, want /api/chat", r.URL.Path)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		if body["model"] != "mistral-test" {
+			t.Errorf("model = %v", body["model"])
+		}
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		for _, line := range lines {
+			fmt.Fprintln(w, line)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return NewMistralGenerator(srv.URL, "mistral-test")
+}
+
+func TestMistralGenerateStream(t *testing.T) {
+	g := ollamaServer(t,
+		`{"model":"mistral-test","message":{"role":"assistant","content":"Hello"},"done":false}`,
+		`{"model":"mistral-test","message":{"role":"assistant","content":" world"},"done":false}`,
+		`{"model":"mistral-test","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":4,"eval_count":2}`,
+	)
+
+	var text strings.Builder
+	var last rag.GenerationChunk
+	err := g.GenerateStream(context.Background(), "hi", rag.GenerateOptions{}, func(c rag.GenerationChunk) {
+		text.WriteString(c.Delta)
+		last = c
+	})
+	if err != nil {
+		t.Fatalf("GenerateStream: %v", err)
+	}
+	if text.String() != "Hello world" {
+		t.Errorf("streamed text = %q", text.String())
+	}
+	if !last.IsLast || last.FinishReason != "stop" || last.Metadata["model"] != "mistral-test" {
+		t.Errorf("final chunk = %+v", last)
+	}
+}
+
+// A body that ends before a done line is a truncated response
+func TestMistralStreamTruncated(t *testing.T) {
+	g := ollamaServer(t, `{"model":"mistral-test","message":{"role":"assistant","content":"Hel"},"done":false}`)
+
+	var chunks []rag.GenerationChunk
+	err := g.GenerateStream(context.Background(), "hi", rag.GenerateOptions{}, func(c rag.GenerationChunk) {
+		chunks = append(chunks, c)
+	})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("err = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if len(chunks) != 1 || chunks[0].IsLast {
+		t.Errorf("chunks = %+v, want one non-final chunk", chunks)
+	}
+}
+
+// Errors inside a 200 response come from the model runner and carry no
+// HTTP status
+func TestMistralProviderError(t *testing.T) {
+	g := ollamaServer(t, `{"error":"model runner has unexpectedly stopped"}`)
+
+	err := g.GenerateStream(context.Background(), "hi", rag.GenerateOptions{}, func(rag.GenerationChunk) {})
+	var providerErr *ProviderError
+	if !errors.As(err, &providerErr) || providerErr.Provider != "ollama" {
+		t.Fatalf("stream err = %v, want an ollama ProviderError", err)
+	}
+	if IsRetryable(err) {
+		t.Error("ProviderError should not be retryable")
+	}
+
+	_, err = g.Generate(context.Background(), "hi", rag.GenerateOptions{})
+	if !errors.As(err, &providerErr) || providerErr.Message != "model runner has unexpectedly stopped" {
+		t.Fatalf("Generate err = %v, want an ollama ProviderError", err)
+	}
+}
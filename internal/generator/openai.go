@@ -1,38 +1,369 @@
 package generator
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
 
 	openai "github.com/sashabaranov/go-openai"
 	"ragframework/internal/rag"
+	"ragframework/internal/tokenizer"
 )
 
 type OpenAIGenerator struct {
 	client *openai.Client
 	model  string
+
+	// Tokenizer counts tokens for CountTokens; nil falls back to an estimate
+	Tokenizer tokenizer.Tokenizer
+
+	// TokenizerErr records why the constructor couldn't load a tokenizer
+	// for the model, e.g. a missing file in RAG_TOKENIZER_DIR
+	TokenizerErr error
+}
+
+// OpenAIConfig points the generator at OpenAI, Azure OpenAI or any server
+// exposing an OpenAI-compatible /v1/chat/completions API (vLLM, llama.cpp
+// server, LM Studio...)
+type OpenAIConfig struct {
+	APIKey string // May be empty for local servers
+	Model  string
+
+	// BaseURL includes the API version path, e.g.
+	// "http://localhost:8000/v1" (default https://api.openai.com/v1).
+	// For Azure it is the resource endpoint, e.g.
+	// "https://my-resource.openai.azure.com".
+	BaseURL string
+
+	// Headers are added to every request (e.g. gateway credentials)
+	Headers map[string]string
+
+	// AzureDeployment switches to Azure conventions: requests go to
+	// /openai/deployments/{AzureDeployment} with an api-key header
+	AzureDeployment string
+
+	// APIVersion is Azure's api-version query parameter (default "2024-10-21")
+	APIVersion string
+
+	// HTTPClient defaults to a plain http.Client
+	HTTPClient *http.Client
 }
 
 func NewOpenAIGenerator(apiKey, model string) *OpenAIGenerator {
-	client := openai.NewClient(apiKey)
-	return &OpenAIGenerator{
-		client: client,
-		model:  model,
+	return NewOpenAIGeneratorWithConfig(OpenAIConfig{APIKey: apiKey, Model: model})
+}
+
+func NewOpenAIGeneratorWithConfig(cfg OpenAIConfig) *OpenAIGenerator {
+	var config openai.ClientConfig
+	if cfg.AzureDeployment != "" {
+		config = openai.DefaultAzureConfig(cfg.APIKey, cfg.BaseURL)
+		config.APIVersion = cfg.APIVersion
+		if config.APIVersion == "" {
+			config.APIVersion = "2024-10-21"
+		}
+		deployment := cfg.AzureDeployment
+		config.AzureModelMapperFunc = func(string) string { return deployment }
+	} else {
+		config = openai.DefaultConfig(cfg.APIKey)
+		if cfg.BaseURL != "" {
+			config.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+		}
+	}
+
+	client := cfg.HTTPClient
+	if client == nil {
+		client = &http.Client{}
+	}
+	withHeaders := *client
+	withHeaders.Transport = &headerTransport{base: client.Transport, headers: cfg.Headers}
+	client = &withHeaders
+	config.HTTPClient = client
+
+	g := &OpenAIGenerator{
+		client: openai.NewClientWithConfig(config),
+		model:  cfg.Model,
+	}
+	g.Tokenizer, g.TokenizerErr = tokenizer.ForModel(cfg.Model)
+	return g
+}
+
+// headerTransport adds fixed headers to each request, hands the response
+// headers back through the request context (see withResponseHeader) since
+// go-openai's errors drop them, and sends a zero temperature when the
+// context asks for one (see withZeroTemperature)
+type headerTransport struct {
+	base    http.RoundTripper
+	headers map[string]string
+}
+
+func (t *headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
 	}
+	if req.Context().Value(zeroTemperatureKey{}) != nil && req.Body != nil {
+		if err := setZeroTemperature(req); err != nil {
+			return nil, err
+		}
+	}
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if header, ok := req.Context().Value(responseHeaderKey{}).(*http.Header); ok && err == nil {
+		*header = resp.Header
+	}
+	return resp, err
+}
+
+type responseHeaderKey struct{}
+
+// withResponseHeader returns a context whose request's response headers
+// are stored in the returned Header
+func withResponseHeader(ctx context.Context) (context.Context, *http.Header) {
+	header := new(http.Header)
+	return context.WithValue(ctx, responseHeaderKey{}, header), header
+}
+
+type zeroTemperatureKey struct{}
+
+// withZeroTemperature marks a context's chat request as needing
+// "temperature": 0, which go-openai drops because the field is omitempty
+// (and an omitted temperature means the API default of 1)
+func withZeroTemperature(ctx context.Context) context.Context {
+	return context.WithValue(ctx, zeroTemperatureKey{}, true)
+}
+
+// setZeroTemperature rewrites a chat request body to carry a temperature
+// of 0
+func setZeroTemperature(req *http.Request) error {
+	raw, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read request body: %w", err)
+	}
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return fmt.Errorf("failed to decode request body: %w", err)
+	}
+	body["temperature"] = json.RawMessage("0")
+	if raw, err = json.Marshal(body); err != nil {
+		return fmt.Errorf("failed to encode request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(raw))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(raw)), nil }
+	req.ContentLength = int64(len(raw))
+	return nil
+}
+
+// requestContext prepares ctx for one chat request: it captures the
+// response headers and flags an explicit zero temperature
+func requestContext(ctx context.Context, opts rag.GenerateOptions) (context.Context, *http.Header) {
+	if opts.Temperature != nil && float32(*opts.Temperature) == 0 {
+		ctx = withZeroTemperature(ctx)
+	}
+	return withResponseHeader(ctx)
+}
+
+// openaiError turns go-openai's HTTP errors, and error events inside a
+// stream, into a StatusError carrying the response's Retry-After, keeping
+// the original as Err
+func openaiError(err error, header http.Header) error {
+	var apiErr *openai.APIError
+	if errors.As(err, &apiErr) {
+		errType, _ := apiErr.Code.(string)
+		if apiErr.Type != "" {
+			errType = apiErr.Type
+		}
+		status := apiErr.HTTPStatusCode
+		if status == 0 {
+			status = openaiErrorStatus(errType)
+		}
+		if status == 0 {
+			return err
+		}
+		return &StatusError{
+			Provider:   "openai",
+			StatusCode: status,
+			Type:       errType,
+			Message:    apiErr.Message,
+			RetryAfter: headerRetryAfter(header),
+			Err:        err,
+		}
+	}
+	var reqErr *openai.RequestError
+	if errors.As(err, &reqErr) && reqErr.HTTPStatusCode != 0 {
+		return &StatusError{
+			Provider:   "openai",
+			StatusCode: reqErr.HTTPStatusCode,
+			Message:    reqErr.Error(),
+			RetryAfter: headerRetryAfter(header),
+			Err:        err,
+		}
+	}
+	return err
+}
+
+// openaiErrorStatus maps the error type of an in-stream error event,
+// which arrives after a 200 response, to the status the API would have
+// returned for it, or 0 for unknown types
+func openaiErrorStatus(errType string) int {
+	switch errType {
+	case "invalid_request_error":
+		return http.StatusBadRequest
+	case "rate_limit_exceeded", "insufficient_quota", "requests", "tokens":
+		return http.StatusTooManyRequests
+	case "server_error":
+		return http.StatusInternalServerError
+	}
+	return 0
 }
 
 func (g *OpenAIGenerator) Generate(ctx context.Context, prompt string, opts rag.GenerateOptions) (*rag.GenerationResult, error) {
-	resp, err := g.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
-		Model: g.model,
-		Messages: []openai.ChatCompletionMessage{
-			{Role: "user", Content: prompt},
-		},
-	})
+	req, err := g.chatRequest(prompt, opts)
 	if err != nil {
 		return nil, err
 	}
 
+	ctx, header := requestContext(ctx, opts)
+	resp, err := g.client.CreateChatCompletion(ctx, req)
+	if err != nil {
+		return nil, openaiError(err, *header)
+	}
+	if len(resp.Choices) == 0 {
+		return nil, fmt.Errorf("openai response has no choices")
+	}
+
 	result := &rag.GenerationResult{
-		Text: resp.Choices[0].Message.Content,
+		Text:         resp.Choices[0].Message.Content,
+		FinishReason: string(resp.Choices[0].FinishReason),
+		Usage: &rag.TokenUsage{
+			Input:  resp.Usage.PromptTokens,
+			Output: resp.Usage.CompletionTokens,
+			Total:  resp.Usage.TotalTokens,
+		},
+		Metadata: map[string]interface{}{"model": resp.Model},
 	}
 	return result, nil
 }
+
+// GenerateStream implements streaming output
+func (g *OpenAIGenerator) GenerateStream(ctx context.Context, prompt string, opts rag.GenerateOptions, onChunk func(rag.GenerationChunk)) error {
+	req, err := g.chatRequest(prompt, opts)
+	if err != nil {
+		return err
+	}
+	req.Stream = true
+
+	ctx, header := requestContext(ctx, opts)
+	stream, err := g.client.CreateChatCompletionStream(ctx, req)
+	if err != nil {
+		return openaiError(err, *header)
+	}
+	defer stream.Close()
+
+	var model, finishReason string
+	for {
+		resp, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			// go-openai also reports a body that ends before [DONE] as
+			// io.EOF; only a complete stream has a finish_reason
+			if finishReason == "" {
+				return fmt.Errorf("stream ended without a finish_reason: %w", io.ErrUnexpectedEOF)
+			}
+			onChunk(rag.GenerationChunk{
+				IsLast:       true,
+				FinishReason: finishReason,
+				Metadata:     map[string]interface{}{"model": model},
+			})
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("stream receive failed: %w", openaiError(err, *header))
+		}
+		if resp.Model != "" {
+			model = resp.Model
+		}
+		if len(resp.Choices) == 0 {
+			continue
+		}
+		if reason := resp.Choices[0].FinishReason; reason != "" {
+			finishReason = string(reason)
+		}
+		if resp.Choices[0].Delta.Content != "" {
+			onChunk(rag.GenerationChunk{Delta: resp.Choices[0].Delta.Content})
+		}
+	}
+}
+
+// chatRequest maps a prompt and GenerateOptions onto a chat completion
+func (g *OpenAIGenerator) chatRequest(prompt string, opts rag.GenerateOptions) (openai.ChatCompletionRequest, error) {
+	var messages []openai.ChatCompletionMessage
+	if opts.SystemPrompt != "" {
+		messages = append(messages, openai.ChatCompletionMessage{Role: "system", Content: opts.SystemPrompt})
+	}
+	messages = append(messages, openai.ChatCompletionMessage{Role: "user", Content: prompt})
+
+	model := g.model
+	if opts.Model != "" {
+		model = opts.Model
+	}
+	req := openai.ChatCompletionRequest{
+		Model:     model,
+		Messages:  messages,
+		MaxTokens: opts.MaxTokens,
+		Stop:      opts.StopSequences,
+	}
+	if opts.Temperature != nil {
+		// A zero is dropped by go-openai's omitempty; requestContext has
+		// the transport add it back
+		req.Temperature = float32(*opts.Temperature)
+	}
+	if err := setResponseFormat(&req, opts); err != nil {
+		return req, err
+	}
+	return req, nil
+}
+
+// setResponseFormat maps a "json" ResponseFormat to OpenAI's structured
+// outputs (json_schema) when a schema is given, or to JSON mode
+// (json_object) otherwise. Options "name" and "strict" tune json_schema.
+func setResponseFormat(req *openai.ChatCompletionRequest, opts rag.GenerateOptions) error {
+	isJSON, schema, err := jsonResponse(opts)
+	if err != nil || !isJSON {
+		return err
+	}
+	if schema == nil {
+		req.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
+		return nil
+	}
+
+	name := opts.ResponseFormat.Options["name"]
+	if name == "" {
+		name = "response"
+	}
+	req.ResponseFormat = &openai.ChatCompletionResponseFormat{
+		Type: openai.ChatCompletionResponseFormatTypeJSONSchema,
+		JSONSchema: &openai.ChatCompletionResponseFormatJSONSchema{
+			Name:   name,
+			Schema: schema,
+			Strict: opts.ResponseFormat.Options["strict"] == "true",
+		},
+	}
+	return nil
+}
+
+// CountTokens uses the model's tiktoken encoding when its rank file is
+// available, and a rough estimate otherwise
+func (g *OpenAIGenerator) CountTokens(text string) int {
+	if g.Tokenizer != nil {
+		return g.Tokenizer.Count(text)
+	}
+	return tokenizer.EstimateCount(text)
+}
@@ -0,0 +1,183 @@
+package generator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"ragframework/internal/rag"
+)
+
+const openaiCompletion = `{"id":"c1","object":"chat.completion","model":"gpt-test","choices":[{"index":0,` +
+	`"message":{"role":"assistant","content":"Hello"},"finish_reason":"stop"}],` +
+	`"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`
+
+// openaiServer serves handler and returns a generator pointed at it
+func openaiServer(t *testing.T, cfg OpenAIConfig, handler http.HandlerFunc) *OpenAIGenerator {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	if cfg.AzureDeployment != "" {
+		cfg.BaseURL = srv.URL
+	} else {
+		cfg.BaseURL = srv.URL + "/v1"
+	}
+	if cfg.Model == "" {
+		cfg.Model = "gpt-test"
+	}
+	cfg.HTTPClient = srv.Client()
+	return NewOpenAIGeneratorWithConfig(cfg)
+}
+
+// streamEvents writes chat completion chunks as server-sent events
+func streamEvents(w http.ResponseWriter, events ...string) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	for _, e := range events {
+		fmt.Fprintf(w, "data: %s\n\n", e)
+	}
+}
+
+func TestOpenAIAzure(t *testing.T) {
+	g := openaiServer(t, OpenAIConfig{APIKey: "azure-key", AzureDeployment: "my-deployment"}, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/my-deployment/chat/completions" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		if v := r.URL.Query().Get("api-version"); v != "2024-10-21" {
+			t.Errorf("api-version = %q", v)
+		}
+		if key := r.Header.Get("api-key"); key != "azure-key" {
+			t.Errorf("api-key = %q", key)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "" {
+			t.Errorf("Authorization = %q, want none", auth)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, openaiCompletion)
+	})
+
+	result, err := g.Generate(context.Background(), "hi", rag.GenerateOptions{})
+	if err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if result.Text != "Hello" || result.FinishReason != "stop" {
+		t.Errorf("result = %+v", result)
+	}
+}
+
+func TestOpenAIHeaders(t *testing.T) {
+	cfg := OpenAIConfig{APIKey: "sk-test", Headers: map[string]string{"X-Gateway-Key": "secret"}}
+	g := openaiServer(t, cfg, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		if v := r.Header.Get("X-Gateway-Key"); v != "secret" {
+			t.Errorf("X-Gateway-Key = %q", v)
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer sk-test" {
+			t.Errorf("Authorization = %q", auth)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, openaiCompletion)
+	})
+
+	if _, err := g.Generate(context.Background(), "hi", rag.GenerateOptions{}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+}
+
+func TestOpenAIZeroTemperature(t *testing.T) {
+	var body map[string]json.RawMessage
+	g := openaiServer(t, OpenAIConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, openaiCompletion)
+	})
+
+	zero := 0.0
+	if _, err := g.Generate(context.Background(), "hi", rag.GenerateOptions{Temperature: &zero}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if got := string(body["temperature"]); got != "0" {
+		t.Errorf("temperature = %q, want 0", got)
+	}
+
+	if _, err := g.Generate(context.Background(), "hi", rag.GenerateOptions{}); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	if _, ok := body["temperature"]; ok {
+		t.Errorf("temperature sent without being set: %s", body["temperature"])
+	}
+}
+
+func TestOpenAIGenerateStream(t *testing.T) {
+	g := openaiServer(t, OpenAIConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		streamEvents(w,
+			`{"model":"gpt-test","choices":[{"index":0,"delta":{"content":"Hello"}}]}`,
+			`{"model":"gpt-test","choices":[{"index":0,"delta":{"content":" world"}}]}`,
+			`{"model":"gpt-test","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+			`[DONE]`,
+		)
+	})
+
+	var text strings.Builder
+	var last rag.GenerationChunk
+	err := g.GenerateStream(context.Background(), "hi", rag.GenerateOptions{}, func(c rag.GenerationChunk) {
+		text.WriteString(c.Delta)
+		last = c
+	})
+	if err != nil {
+		t.Fatalf("GenerateStream: %v", err)
+	}
+	if text.String() != "Hello world" {
+		t.Errorf("streamed text = %q", text.String())
+	}
+	if !last.IsLast || last.FinishReason != "stop" || last.Metadata["model"] != "gpt-test" {
+		t.Errorf("final chunk = %+v", last)
+	}
+}
+
+// A body that ends before a finish_reason is a truncated response
+func TestOpenAIStreamTruncated(t *testing.T) {
+	g := openaiServer(t, OpenAIConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		streamEvents(w, `{"model":"gpt-test","choices":[{"index":0,"delta":{"content":"Hel"}}]}`)
+	})
+
+	var chunks []rag.GenerationChunk
+	err := g.GenerateStream(context.Background(), "hi", rag.GenerateOptions{}, func(c rag.GenerationChunk) {
+		chunks = append(chunks, c)
+	})
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("err = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if len(chunks) != 1 || chunks[0].IsLast {
+		t.Errorf("chunks = %+v, want one non-final chunk", chunks)
+	}
+}
+
+func TestOpenAIStreamError(t *testing.T) {
+	g := openaiServer(t, OpenAIConfig{}, func(w http.ResponseWriter, r *http.Request) {
+		streamEvents(w,
+			`{"model":"gpt-test","choices":[{"index":0,"delta":{"content":"Hel"}}]}`,
+			`{"error":{"message":"The server had an error","type":"server_error"}}`,
+		)
+	})
+
+	err := g.GenerateStream(context.Background(), "hi", rag.GenerateOptions{}, func(rag.GenerationChunk) {})
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusInternalServerError || statusErr.Type != "server_error" {
+		t.Fatalf("err = %v, want a 500 server_error StatusError", err)
+	}
+	if !IsRetryable(err) {
+		t.Error("server_error should be retryable")
+	}
+}
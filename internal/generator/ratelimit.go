@@ -0,0 +1,147 @@
+package generator
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"ragframework/internal/rag"
+)
+
+// RateLimitGenerator keeps calls within requests-per-minute and
+// tokens-per-minute budgets, waiting client-side instead of being
+// rejected with 429s. Tokens are estimated up front from the prompt and
+// MaxTokens. Generate then corrects the estimate with the reported usage;
+// streams are charged the estimate, as their chunks don't carry usage.
+type RateLimitGenerator struct {
+	Generator rag.Generator
+
+	requests *tokenBucket // nil = unlimited
+	tokens   *tokenBucket
+}
+
+// NewRateLimitGenerator limits calls to requestsPerMinute and
+// tokensPerMinute; 0 leaves that dimension unlimited
+func NewRateLimitGenerator(generator rag.Generator, requestsPerMinute, tokensPerMinute int) *RateLimitGenerator {
+	rl := &RateLimitGenerator{Generator: generator}
+	if requestsPerMinute > 0 {
+		rl.requests = newTokenBucket(requestsPerMinute)
+	}
+	if tokensPerMinute > 0 {
+		rl.tokens = newTokenBucket(tokensPerMinute)
+	}
+	return rl
+}
+
+func (rl *RateLimitGenerator) Generate(ctx context.Context, prompt string, opts rag.GenerateOptions) (*rag.GenerationResult, error) {
+	estimate, err := rl.acquire(ctx, prompt, opts)
+	if err != nil {
+		return nil, err
+	}
+	result, err := rl.Generator.Generate(ctx, prompt, opts)
+	if rl.tokens != nil {
+		switch {
+		case err != nil:
+			// Failed calls aren't billed, so the retry doesn't pay twice
+			rl.tokens.adjust(estimate)
+		case result.Usage != nil && result.Usage.Total > 0:
+			rl.tokens.adjust(estimate - result.Usage.Total)
+		}
+	}
+	return result, err
+}
+
+func (rl *RateLimitGenerator) GenerateStream(ctx context.Context, prompt string, opts rag.GenerateOptions, onChunk func(rag.GenerationChunk)) error {
+	estimate, err := rl.acquire(ctx, prompt, opts)
+	if err != nil {
+		return err
+	}
+	started := false
+	err = rl.Generator.GenerateStream(ctx, prompt, opts, func(chunk rag.GenerationChunk) {
+		started = true
+		onChunk(chunk)
+	})
+	if err != nil && !started && rl.tokens != nil {
+		rl.tokens.adjust(estimate)
+	}
+	return err
+}
+
+func (rl *RateLimitGenerator) CountTokens(text string) int {
+	return rl.Generator.CountTokens(text)
+}
+
+// acquire waits for one request and the estimated tokens, returning the
+// estimate
+func (rl *RateLimitGenerator) acquire(ctx context.Context, prompt string, opts rag.GenerateOptions) (int, error) {
+	if rl.requests != nil {
+		if err := rl.requests.wait(ctx, 1); err != nil {
+			return 0, err
+		}
+	}
+	if rl.tokens == nil {
+		return 0, nil
+	}
+	estimate := rl.Generator.CountTokens(opts.SystemPrompt+prompt) + opts.MaxTokens
+	if err := rl.tokens.wait(ctx, estimate); err != nil {
+		return 0, err
+	}
+	return estimate, nil
+}
+
+// tokenBucket refills continuously at perMinute/60 per second up to
+// perMinute. A request larger than the whole bucket is let through once
+// the bucket is full, leaving it in debt.
+type tokenBucket struct {
+	mu        sync.Mutex
+	capacity  float64
+	available float64
+	perSecond float64
+	updated   time.Time
+}
+
+func newTokenBucket(perMinute int) *tokenBucket {
+	return &tokenBucket{
+		capacity:  float64(perMinute),
+		available: float64(perMinute),
+		perSecond: float64(perMinute) / 60,
+		updated:   time.Now(),
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	b.available = min(b.capacity, b.available+now.Sub(b.updated).Seconds()*b.perSecond)
+	b.updated = now
+}
+
+// wait blocks until n units can be taken, or ctx ends
+func (b *tokenBucket) wait(ctx context.Context, n int) error {
+	need := float64(n)
+	for {
+		b.mu.Lock()
+		b.refill(time.Now())
+		if b.available >= min(need, b.capacity) {
+			b.available -= need
+			b.mu.Unlock()
+			return nil
+		}
+		shortfall := min(need, b.capacity) - b.available
+		b.mu.Unlock()
+
+		timer := time.NewTimer(time.Duration(shortfall / b.perSecond * float64(time.Second)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// adjust returns (or, when negative, takes) units after the real cost is known
+func (b *tokenBucket) adjust(n int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(time.Now())
+	b.available = min(b.capacity, b.available+float64(n))
+}
@@ -0,0 +1,100 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"ragframework/internal/rag"
+)
+
+// near reports whether a bucket's balance is within one unit of want,
+// allowing for refill while the test runs
+func near(b *tokenBucket, want float64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return math.Abs(b.available-want) < 1
+}
+
+func TestTokenBucketWait(t *testing.T) {
+	b := newTokenBucket(6000) // 100 per second
+	if err := b.wait(context.Background(), 6000); err != nil {
+		t.Fatal(err)
+	}
+	if !near(b, 0) {
+		t.Fatalf("available = %v, want 0", b.available)
+	}
+
+	start := time.Now()
+	if err := b.wait(context.Background(), 10); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("waited %v for 10 units at 100/s, want about 100ms", elapsed)
+	}
+}
+
+func TestTokenBucketWaitCanceled(t *testing.T) {
+	b := newTokenBucket(60) // 1 per second
+	b.available = 0
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := b.wait(ctx, 30); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want DeadlineExceeded", err)
+	}
+	if !near(b, 0) {
+		t.Errorf("available = %v, want nothing taken", b.available)
+	}
+}
+
+// A request above capacity goes through once the bucket is full, leaving
+// it in debt
+func TestTokenBucketOversized(t *testing.T) {
+	b := newTokenBucket(100)
+	if err := b.wait(context.Background(), 250); err != nil {
+		t.Fatal(err)
+	}
+	if !near(b, -150) {
+		t.Errorf("available = %v, want -150", b.available)
+	}
+}
+
+func TestTokenBucketAdjust(t *testing.T) {
+	b := newTokenBucket(60)
+	b.available = 10
+
+	b.adjust(-30) // Cost more than estimated
+	if !near(b, -20) {
+		t.Errorf("available = %v, want -20", b.available)
+	}
+	b.adjust(1000) // Refunds stop at capacity
+	if !near(b, 60) {
+		t.Errorf("available = %v, want 60", b.available)
+	}
+}
+
+func TestRateLimitCorrectsEstimate(t *testing.T) {
+	stub := &stubGenerator{usage: &rag.TokenUsage{Total: 30}}
+	rl := NewRateLimitGenerator(stub, 0, 1000)
+
+	// The stub counts a token per byte: 5 for the prompt plus MaxTokens
+	if _, err := rl.Generate(context.Background(), "hello", rag.GenerateOptions{MaxTokens: 95}); err != nil {
+		t.Fatal(err)
+	}
+	if !near(rl.tokens, 1000-30) {
+		t.Errorf("available = %v, want the 30 tokens used taken", rl.tokens.available)
+	}
+
+	// A failed call is refunded
+	stub.errs = []error{statusErr(500), statusErr(500)}
+	stub.calls = 0
+	if _, err := rl.Generate(context.Background(), "hello", rag.GenerateOptions{MaxTokens: 95}); err == nil {
+		t.Fatal("Generate succeeded")
+	}
+	if !near(rl.tokens, 1000-30) {
+		t.Errorf("available = %v, want the failed call refunded", rl.tokens.available)
+	}
+}
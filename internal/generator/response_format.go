@@ -0,0 +1,25 @@
+package generator
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"ragframework/internal/rag"
+)
+
+// jsonResponse reports whether opts ask for JSON output and returns the
+// encoded schema, which is nil when any JSON object is accepted
+func jsonResponse(opts rag.GenerateOptions) (bool, json.RawMessage, error) {
+	rf := opts.ResponseFormat
+	if rf == nil || rf.Type != "json" {
+		return false, nil, nil
+	}
+	if rf.Schema == nil {
+		return true, nil, nil
+	}
+	schema, err := json.Marshal(rf.Schema)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to encode response schema: %w", err)
+	}
+	return true, schema, nil
+}
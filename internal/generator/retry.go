@@ -0,0 +1,127 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"time"
+
+	"ragframework/internal/rag"
+)
+
+// RetryGenerator retries calls that fail with a retryable error (see
+// IsRetryable) using exponential backoff with jitter. A server's
+// Retry-After is honoured when it asks for a longer wait. Streams are only
+// retried if no chunk has been delivered yet.
+type RetryGenerator struct {
+	Generator rag.Generator
+
+	// MaxRetries is the number of retries after the first attempt; 0
+	// disables retrying and a negative value uses the default of 3
+	MaxRetries int
+
+	// BaseDelay is the first backoff delay, doubled on each retry (default 500ms)
+	BaseDelay time.Duration
+
+	// MaxDelay caps each wait; a Retry-After beyond it ends the retries
+	// (default 30s)
+	MaxDelay time.Duration
+}
+
+func NewRetryGenerator(generator rag.Generator, maxRetries int) *RetryGenerator {
+	return &RetryGenerator{
+		Generator:  generator,
+		MaxRetries: maxRetries,
+		BaseDelay:  500 * time.Millisecond,
+		MaxDelay:   30 * time.Second,
+	}
+}
+
+func (r *RetryGenerator) Generate(ctx context.Context, prompt string, opts rag.GenerateOptions) (*rag.GenerationResult, error) {
+	var result *rag.GenerationResult
+	err := r.do(ctx, func() (bool, error) {
+		var err error
+		result, err = r.Generator.Generate(ctx, prompt, opts)
+		return true, err
+	})
+	return result, err
+}
+
+func (r *RetryGenerator) GenerateStream(ctx context.Context, prompt string, opts rag.GenerateOptions, onChunk func(rag.GenerationChunk)) error {
+	return r.do(ctx, func() (bool, error) {
+		started := false
+		err := r.Generator.GenerateStream(ctx, prompt, opts, func(chunk rag.GenerationChunk) {
+			started = true
+			onChunk(chunk)
+		})
+		return !started, err
+	})
+}
+
+func (r *RetryGenerator) CountTokens(text string) int {
+	return r.Generator.CountTokens(text)
+}
+
+// do runs attempt until it succeeds, fails for good, or reports that it
+// can no longer be retried
+func (r *RetryGenerator) do(ctx context.Context, attempt func() (retryable bool, err error)) error {
+	maxRetries := r.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 3
+	}
+
+	for n := 0; ; n++ {
+		canRetry, err := attempt()
+		if err == nil || !canRetry || n >= maxRetries || !r.shouldRetry(ctx, err) {
+			return err
+		}
+
+		delay, ok := r.delay(n, err)
+		if !ok {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// shouldRetry also retries per-call timeouts (see TimeoutGenerator) as long
+// as the caller's own context is still live
+func (r *RetryGenerator) shouldRetry(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	return IsRetryable(err) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// delay picks the wait before retry n: a random duration between half and
+// all of BaseDelay·2ⁿ, or the server's Retry-After if that is longer
+func (r *RetryGenerator) delay(n int, err error) (time.Duration, bool) {
+	base := r.BaseDelay
+	if base <= 0 {
+		base = 500 * time.Millisecond
+	}
+	maxDelay := r.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = 30 * time.Second
+	}
+
+	backoff := min(base<<n, maxDelay)
+	if backoff <= 0 { // Overflow
+		backoff = maxDelay
+	}
+	backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+
+	if after := retryAfter(err); after > backoff {
+		if after > maxDelay {
+			return 0, false
+		}
+		return after, true
+	}
+	return backoff, true
+}
@@ -0,0 +1,204 @@
+package generator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	openai "github.com/sashabaranov/go-openai"
+	"ragframework/internal/rag"
+)
+
+// stubGenerator fails its first calls with errs in turn and answers the
+// rest. Streams send deltas before the call's error or final chunk.
+type stubGenerator struct {
+	errs   []error
+	deltas []string
+	finish string // Final FinishReason (default "stop")
+	model  string // Reported in Metadata["model"] when set
+	usage  *rag.TokenUsage
+	calls  int
+}
+
+func (s *stubGenerator) next() error {
+	s.calls++
+	if s.calls <= len(s.errs) {
+		return s.errs[s.calls-1]
+	}
+	return nil
+}
+
+func (s *stubGenerator) finishReason() string {
+	if s.finish == "" {
+		return "stop"
+	}
+	return s.finish
+}
+
+func (s *stubGenerator) metadata() map[string]interface{} {
+	if s.model == "" {
+		return nil
+	}
+	return map[string]interface{}{"model": s.model}
+}
+
+func (s *stubGenerator) Generate(ctx context.Context, prompt string, opts rag.GenerateOptions) (*rag.GenerationResult, error) {
+	if err := s.next(); err != nil {
+		return nil, err
+	}
+	return &rag.GenerationResult{
+		Text:         strings.Join(s.deltas, ""),
+		Usage:        s.usage,
+		FinishReason: s.finishReason(),
+		Metadata:     s.metadata(),
+	}, nil
+}
+
+func (s *stubGenerator) GenerateStream(ctx context.Context, prompt string, opts rag.GenerateOptions, onChunk func(rag.GenerationChunk)) error {
+	err := s.next()
+	for _, d := range s.deltas {
+		onChunk(rag.GenerationChunk{Delta: d})
+	}
+	if err != nil {
+		return err
+	}
+	onChunk(rag.GenerationChunk{IsLast: true, FinishReason: s.finishReason(), Metadata: s.metadata()})
+	return nil
+}
+
+func (s *stubGenerator) CountTokens(text string) int {
+	return len(text)
+}
+
+func statusErr(code int) error {
+	return &StatusError{Provider: "test", StatusCode: code, Message: http.StatusText(code)}
+}
+
+func TestIsRetryable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{context.Canceled, false},
+		{errors.New("bad prompt"), false},
+		{statusErr(http.StatusBadRequest), false},
+		{statusErr(http.StatusRequestTimeout), true},
+		{statusErr(http.StatusTooManyRequests), true},
+		{statusErr(http.StatusInternalServerError), true},
+		{statusErr(529), true},
+		{fmt.Errorf("call failed: %w", statusErr(http.StatusServiceUnavailable)), true},
+		{&openai.APIError{HTTPStatusCode: http.StatusBadGateway}, true},
+		{&openai.RequestError{HTTPStatusCode: http.StatusUnauthorized}, false},
+		{&net.OpError{Op: "dial", Err: errors.New("connection refused")}, true},
+		{&ProviderError{Provider: "ollama", Message: "model runner crashed"}, false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRetryDelayBackoff(t *testing.T) {
+	r := &RetryGenerator{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	err := statusErr(http.StatusServiceUnavailable)
+
+	for n := 0; n < 8; n++ {
+		backoff := min(r.BaseDelay<<n, r.MaxDelay)
+		for i := 0; i < 50; i++ {
+			d, ok := r.delay(n, err)
+			if !ok || d < backoff/2 || d > backoff {
+				t.Fatalf("delay(%d) = %v, %v; want within [%v, %v]", n, d, ok, backoff/2, backoff)
+			}
+		}
+	}
+
+	// Shifting past the width of a Duration still caps at MaxDelay
+	if d, ok := r.delay(80, err); !ok || d < r.MaxDelay/2 || d > r.MaxDelay {
+		t.Errorf("delay(80) = %v, %v", d, ok)
+	}
+}
+
+func TestRetryDelayRetryAfter(t *testing.T) {
+	r := &RetryGenerator{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+
+	err := &StatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 400 * time.Millisecond}
+	if d, ok := r.delay(0, err); !ok || d != 400*time.Millisecond {
+		t.Errorf("delay = %v, %v; want the 400ms Retry-After", d, ok)
+	}
+
+	// A Retry-After shorter than the backoff doesn't shorten it
+	err.RetryAfter = time.Millisecond
+	if d, ok := r.delay(0, err); !ok || d < 50*time.Millisecond {
+		t.Errorf("delay = %v, %v; want the backoff", d, ok)
+	}
+
+	// A Retry-After beyond MaxDelay ends the retries
+	err.RetryAfter = 2 * time.Second
+	if _, ok := r.delay(0, err); ok {
+		t.Error("Retry-After above MaxDelay was accepted")
+	}
+}
+
+func TestRetryMaxRetries(t *testing.T) {
+	tests := []struct {
+		maxRetries int
+		wantCalls  int
+	}{
+		{0, 1},
+		{2, 3},
+		{-1, 4}, // Default of 3
+	}
+	for _, tt := range tests {
+		errs := make([]error, 10)
+		for i := range errs {
+			errs[i] = statusErr(http.StatusServiceUnavailable)
+		}
+		stub := &stubGenerator{errs: errs}
+		r := NewRetryGenerator(stub, tt.maxRetries)
+		r.BaseDelay = time.Nanosecond
+
+		if _, err := r.Generate(context.Background(), "hi", rag.GenerateOptions{}); err == nil {
+			t.Errorf("MaxRetries %d: Generate succeeded", tt.maxRetries)
+		}
+		if stub.calls != tt.wantCalls {
+			t.Errorf("MaxRetries %d: %d calls, want %d", tt.maxRetries, stub.calls, tt.wantCalls)
+		}
+	}
+}
+
+func TestRetryRecovers(t *testing.T) {
+	stub := &stubGenerator{errs: []error{statusErr(http.StatusTooManyRequests)}, deltas: []string{"ok"}}
+	r := NewRetryGenerator(stub, 3)
+	r.BaseDelay = time.Nanosecond
+
+	result, err := r.Generate(context.Background(), "hi", rag.GenerateOptions{})
+	if err != nil || result.Text != "ok" || stub.calls != 2 {
+		t.Errorf("result = %+v, err = %v, calls = %d", result, err, stub.calls)
+	}
+
+	// Non-retryable errors are returned at once
+	stub = &stubGenerator{errs: []error{statusErr(http.StatusBadRequest)}}
+	r.Generator = stub
+	if _, err := r.Generate(context.Background(), "hi", rag.GenerateOptions{}); err == nil || stub.calls != 1 {
+		t.Errorf("err = %v, calls = %d", err, stub.calls)
+	}
+}
+
+// A stream that already delivered a chunk can't be retried
+func TestRetryStreamAfterFirstChunk(t *testing.T) {
+	stub := &stubGenerator{errs: []error{statusErr(http.StatusServiceUnavailable)}, deltas: []string{"Hel"}}
+	r := NewRetryGenerator(stub, 3)
+	r.BaseDelay = time.Nanosecond
+
+	err := r.GenerateStream(context.Background(), "hi", rag.GenerateOptions{}, func(rag.GenerationChunk) {})
+	if err == nil || stub.calls != 1 {
+		t.Errorf("err = %v, calls = %d; want the first error", err, stub.calls)
+	}
+}
@@ -0,0 +1,48 @@
+package generator
+
+import (
+	"context"
+	"time"
+
+	"ragframework/internal/rag"
+)
+
+// TimeoutGenerator bounds each call, including a whole stream, to Timeout.
+// Wrapped in a RetryGenerator, calls that time out are retried.
+//
+//	gen := NewRetryGenerator(NewTimeoutGenerator(NewRateLimitGenerator(base, 500, 200000), 30*time.Second), 3)
+type TimeoutGenerator struct {
+	Generator rag.Generator
+	Timeout   time.Duration
+}
+
+func NewTimeoutGenerator(generator rag.Generator, timeout time.Duration) *TimeoutGenerator {
+	return &TimeoutGenerator{
+		Generator: generator,
+		Timeout:   timeout,
+	}
+}
+
+func (t *TimeoutGenerator) Generate(ctx context.Context, prompt string, opts rag.GenerateOptions) (*rag.GenerationResult, error) {
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+	return t.Generator.Generate(ctx, prompt, opts)
+}
+
+func (t *TimeoutGenerator) GenerateStream(ctx context.Context, prompt string, opts rag.GenerateOptions, onChunk func(rag.GenerationChunk)) error {
+	ctx, cancel := t.context(ctx)
+	defer cancel()
+	return t.Generator.GenerateStream(ctx, prompt, opts, onChunk)
+}
+
+func (t *TimeoutGenerator) CountTokens(text string) int {
+	return t.Generator.CountTokens(text)
+}
+
+// context applies Timeout; zero or negative means no limit
+func (t *TimeoutGenerator) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, t.Timeout)
+}
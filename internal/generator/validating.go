@@ -0,0 +1,130 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"ragframework/internal/jsonschema"
+	"ragframework/internal/rag"
+)
+
+// ValidatingGenerator enforces "json" ResponseFormats on any Generator:
+// the output is checked against the schema, and on failure the model is
+// asked again with the validation errors, up to MaxAttempts calls in
+// total. Requests without a JSON format pass straight through.
+type ValidatingGenerator struct {
+	Generator rag.Generator
+
+	// MaxAttempts caps generation calls per request (default 3)
+	MaxAttempts int
+}
+
+func NewValidatingGenerator(generator rag.Generator, maxAttempts int) *ValidatingGenerator {
+	return &ValidatingGenerator{
+		Generator:   generator,
+		MaxAttempts: maxAttempts,
+	}
+}
+
+// Generate returns the first response that validates, with Text reduced
+// to the JSON document itself
+func (v *ValidatingGenerator) Generate(ctx context.Context, prompt string, opts rag.GenerateOptions) (*rag.GenerationResult, error) {
+	if opts.ResponseFormat == nil || opts.ResponseFormat.Type != "json" {
+		return v.Generator.Generate(ctx, prompt, opts)
+	}
+
+	var schemaDoc interface{} = map[string]interface{}{}
+	if opts.ResponseFormat.Schema != nil {
+		schemaDoc = opts.ResponseFormat.Schema
+	}
+	schema, err := jsonschema.Compile(schemaDoc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response schema: %w", err)
+	}
+
+	attempts := v.MaxAttempts
+	if attempts <= 0 {
+		attempts = 3
+	}
+
+	current := prompt
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		result, err := v.Generator.Generate(ctx, current, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		doc := extractJSON(result.Text)
+		if lastErr = schema.Validate([]byte(doc)); lastErr == nil {
+			result.Text = doc
+			return result, nil
+		}
+		current = repairPrompt(prompt, doc, lastErr)
+	}
+	return nil, fmt.Errorf("response failed validation after %d attempts: %w", attempts, lastErr)
+}
+
+// GenerateStream can't take back streamed text, so JSON requests are
+// generated and validated in full and delivered as a single chunk
+func (v *ValidatingGenerator) GenerateStream(ctx context.Context, prompt string, opts rag.GenerateOptions, onChunk func(rag.GenerationChunk)) error {
+	if opts.ResponseFormat == nil || opts.ResponseFormat.Type != "json" {
+		return v.Generator.GenerateStream(ctx, prompt, opts, onChunk)
+	}
+
+	result, err := v.Generate(ctx, prompt, opts)
+	if err != nil {
+		return err
+	}
+	onChunk(rag.GenerationChunk{
+		Delta:        result.Text,
+		IsLast:       true,
+		FinishReason: result.FinishReason,
+		Metadata:     result.Metadata,
+	})
+	return nil
+}
+
+func (v *ValidatingGenerator) CountTokens(text string) int {
+	return v.Generator.CountTokens(text)
+}
+
+// repairPrompt repeats the original request with the rejected output and
+// what was wrong with it
+func repairPrompt(prompt, output string, err error) string {
+	var sb strings.Builder
+	sb.WriteString(prompt)
+	sb.WriteString("\n\nYour previous response was:\n")
+	sb.WriteString(output)
+	sb.WriteString("\n\nIt was rejected because: ")
+	sb.WriteString(err.Error())
+	sb.WriteString("\nRespond again with only the corrected JSON.")
+	return sb.String()
+}
+
+// extractJSON pulls the JSON document out of a response, dropping Markdown
+// code fences and any prose around the outermost object or array
+func extractJSON(text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "```") {
+		text = strings.TrimPrefix(text, "```json")
+		text = strings.TrimPrefix(text, "```")
+		text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+		text = strings.TrimSpace(text)
+	}
+
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return text
+	}
+	closing := "}"
+	if text[start] == '[' {
+		closing = "]"
+	}
+	end := strings.LastIndex(text, closing)
+	if end < start {
+		return text
+	}
+	return text[start : end+1]
+}
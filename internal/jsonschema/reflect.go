@@ -0,0 +1,210 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Reflect derives a JSON Schema from a Go value's type, following
+// encoding/json field names. Struct fields are required unless tagged
+// omitempty, and structs reject unknown properties. A `jsonschema` tag
+// adds comma-separated keywords:
+//
+//	Name  string   `json:"name" jsonschema:"description=Full name,minLength=1"`
+//	Kind  string   `json:"kind" jsonschema:"enum=person|company"`
+//	Score float64  `json:"score,omitempty" jsonschema:"minimum=0,maximum=1,required"`
+//	Notes string   `json:"notes" jsonschema:"optional"`
+//
+// Keyword values can't contain commas. Recursive types are emitted once
+// under "$defs" and referenced with "$ref".
+func Reflect(v interface{}) map[string]interface{} {
+	return ReflectType(reflect.TypeOf(v))
+}
+
+// ReflectType is Reflect for a reflect.Type, e.g. of a generic parameter
+func ReflectType(t reflect.Type) map[string]interface{} {
+	r := &reflector{
+		defs:       make(map[string]interface{}),
+		inProgress: make(map[reflect.Type]bool),
+		referenced: make(map[reflect.Type]bool),
+	}
+	schema := r.schemaFor(t)
+	if len(r.defs) > 0 {
+		schema["$defs"] = r.defs
+	}
+	return schema
+}
+
+type reflector struct {
+	defs       map[string]interface{}
+	inProgress map[reflect.Type]bool
+	referenced map[reflect.Type]bool
+}
+
+var (
+	timeType      = reflect.TypeOf(time.Time{})
+	rawType       = reflect.TypeOf(json.RawMessage{})
+	marshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+func (r *reflector) schemaFor(t reflect.Type) map[string]interface{} {
+	if t == nil {
+		return map[string]interface{}{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return map[string]interface{}{"type": "string", "format": "date-time"}
+	case t == rawType || t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType):
+		return map[string]interface{}{} // Custom encoding: accept anything
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string"} // Base64 bytes
+		}
+		return map[string]interface{}{"type": "array", "items": r.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": r.schemaFor(t.Elem())}
+	case reflect.Struct:
+		return r.structSchema(t)
+	}
+	return map[string]interface{}{}
+}
+
+func (r *reflector) structSchema(t reflect.Type) map[string]interface{} {
+	name := t.Name()
+	if name != "" && r.inProgress[t] {
+		r.referenced[t] = true
+		return map[string]interface{}{"$ref": "#/$defs/" + name}
+	}
+	r.inProgress[t] = true
+	defer delete(r.inProgress, t)
+
+	properties := make(map[string]interface{})
+	required := []string{}
+	r.addFields(t, properties, &required)
+
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	if r.referenced[t] {
+		// A copy, so adding "$defs" to a recursive root can't make a cycle
+		def := make(map[string]interface{}, len(schema))
+		for k, v := range schema {
+			def[k] = v
+		}
+		r.defs[name] = def
+	}
+	return schema
+}
+
+// addFields collects a struct's properties, flattening embedded structs
+// the way encoding/json does
+func (r *reflector) addFields(t reflect.Type, properties map[string]interface{}, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				r.addFields(ft, properties, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		schema := r.schemaFor(f.Type)
+		isRequired := !strings.Contains(","+opts+",", ",omitempty,")
+		if kw := f.Tag.Get("jsonschema"); kw != "" {
+			isRequired = applyKeywords(schema, kw, isRequired)
+		}
+		properties[name] = schema
+		if isRequired {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// applyKeywords adds `jsonschema` tag keywords to a field schema and
+// returns whether the field is required
+func applyKeywords(schema map[string]interface{}, tag string, required bool) bool {
+	for _, kw := range strings.Split(tag, ",") {
+		key, value, hasValue := strings.Cut(strings.TrimSpace(kw), "=")
+		switch key {
+		case "required":
+			required = true
+		case "optional":
+			required = false
+		case "enum":
+			// On slices the allowed values apply to each item
+			target := schema
+			if items, ok := schema["items"].(map[string]interface{}); ok {
+				target = items
+			}
+			var values []interface{}
+			for _, v := range strings.Split(value, "|") {
+				values = append(values, typedValue(target, v))
+			}
+			target["enum"] = values
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum",
+			"minLength", "maxLength", "minItems", "maxItems":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				schema[key] = n
+			}
+		case "":
+		default:
+			if hasValue {
+				schema[key] = value // description, pattern, format, title...
+			}
+		}
+	}
+	return required
+}
+
+// typedValue converts an enum entry to the field's JSON type
+func typedValue(schema map[string]interface{}, v string) interface{} {
+	switch schema["type"] {
+	case "integer", "number":
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			return n
+		}
+	case "boolean":
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return v
+}
@@ -0,0 +1,410 @@
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Schema is a compiled JSON Schema. It supports the subset used to
+// describe LLM output: type, properties, required, additionalProperties,
+// items, enum, const, numeric and length bounds, pattern, allOf, anyOf,
+// oneOf, not and local "$ref"s into "$defs" / "definitions".
+type Schema struct {
+	root     map[string]interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// ValidationError is one violation, located by a path such as
+// "$.items[2].name"
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors lists every violation found in a document
+type ValidationErrors []ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, v := range e {
+		msgs[i] = v.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Compile prepares a schema given as a map, JSON bytes or string, or any
+// value that marshals to a JSON Schema object
+func Compile(schema interface{}) (*Schema, error) {
+	var raw []byte
+	switch s := schema.(type) {
+	case []byte:
+		raw = s
+	case json.RawMessage:
+		raw = s
+	case string:
+		raw = []byte(s)
+	default:
+		var err error
+		if raw, err = json.Marshal(schema); err != nil {
+			return nil, fmt.Errorf("failed to encode schema: %w", err)
+		}
+	}
+
+	var root map[string]interface{}
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil, fmt.Errorf("schema is not a JSON object: %w", err)
+	}
+
+	s := &Schema{root: root, patterns: make(map[string]*regexp.Regexp)}
+	if err := s.compilePatterns(root); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// compilePatterns checks every "pattern" up front so validation can't fail
+// on a bad schema
+func (s *Schema) compilePatterns(v interface{}) error {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if p, ok := v["pattern"].(string); ok {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				return fmt.Errorf("invalid schema pattern %q: %w", p, err)
+			}
+			s.patterns[p] = re
+		}
+		for _, child := range v {
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if err := s.compilePatterns(child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// MarshalJSON returns the schema document, e.g. for provider JSON modes
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.root)
+}
+
+// Validate parses a JSON document and checks it against the schema
+func (s *Schema) Validate(data []byte) error {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	if dec.More() {
+		return fmt.Errorf("invalid JSON: unexpected data after the top-level value")
+	}
+	return s.ValidateValue(v)
+}
+
+// ValidateValue checks an already decoded JSON value
+func (s *Schema) ValidateValue(v interface{}) error {
+	var errs ValidationErrors
+	s.validate(s.root, v, "$", &errs, 0)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// maxRefDepth stops self-referencing schemas from recursing forever
+const maxRefDepth = 64
+
+func (s *Schema) validate(schema map[string]interface{}, v interface{}, path string, errs *ValidationErrors, depth int) {
+	fail := func(format string, args ...interface{}) {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := s.resolve(ref)
+		if err != nil || depth >= maxRefDepth {
+			fail("unresolvable $ref %q", ref)
+			return
+		}
+		s.validate(target, v, path, errs, depth+1)
+	}
+
+	if t, ok := schema["type"]; ok && !matchesType(t, v) {
+		fail("expected %s, got %s", describeType(t), typeOf(v))
+		return
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, v) {
+		fail("must be one of %s", compactJSON(enum))
+	}
+	if c, ok := schema["const"]; ok && !equalValues(c, v) {
+		fail("must equal %s", compactJSON(c))
+	}
+
+	switch v := v.(type) {
+	case map[string]interface{}:
+		s.validateObject(schema, v, path, errs, depth)
+	case []interface{}:
+		s.validateArray(schema, v, path, errs, depth)
+	case string:
+		n := float64(len([]rune(v)))
+		if min, ok := number(schema["minLength"]); ok && n < min {
+			fail("must be at least %v characters", min)
+		}
+		if max, ok := number(schema["maxLength"]); ok && n > max {
+			fail("must be at most %v characters", max)
+		}
+		if p, ok := schema["pattern"].(string); ok && !s.patterns[p].MatchString(v) {
+			fail("must match pattern %q", p)
+		}
+	case json.Number:
+		n, _ := v.Float64()
+		if min, ok := number(schema["minimum"]); ok && n < min {
+			fail("must be >= %v", min)
+		}
+		if max, ok := number(schema["maximum"]); ok && n > max {
+			fail("must be <= %v", max)
+		}
+		if min, ok := number(schema["exclusiveMinimum"]); ok && n <= min {
+			fail("must be > %v", min)
+		}
+		if max, ok := number(schema["exclusiveMaximum"]); ok && n >= max {
+			fail("must be < %v", max)
+		}
+	}
+
+	if all, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			if m, ok := sub.(map[string]interface{}); ok {
+				s.validate(m, v, path, errs, depth+1)
+			}
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok && s.countMatches(anyOf, v, depth) == 0 {
+		fail("must match at least one of the anyOf schemas")
+	}
+	if one, ok := schema["oneOf"].([]interface{}); ok {
+		if n := s.countMatches(one, v, depth); n != 1 {
+			fail("must match exactly one of the oneOf schemas, matched %d", n)
+		}
+	}
+	if not, ok := schema["not"].(map[string]interface{}); ok && s.matches(not, v, depth) {
+		fail("must not match the \"not\" schema")
+	}
+}
+
+func (s *Schema) validateObject(schema map[string]interface{}, obj map[string]interface{}, path string, errs *ValidationErrors, depth int) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, present := obj[name]; !present {
+					*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("missing required property %q", name)})
+				}
+			}
+		}
+	}
+
+	// Sorted so error messages, which are shown to the model, are stable
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	for _, name := range names {
+		value := obj[name]
+		childPath := path + "." + name
+		if sub, ok := properties[name].(map[string]interface{}); ok {
+			s.validate(sub, value, childPath, errs, depth+1)
+			continue
+		}
+		switch extra := schema["additionalProperties"].(type) {
+		case bool:
+			if !extra {
+				*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("unexpected property %q", name)})
+			}
+		case map[string]interface{}:
+			s.validate(extra, value, childPath, errs, depth+1)
+		}
+	}
+}
+
+func (s *Schema) validateArray(schema map[string]interface{}, arr []interface{}, path string, errs *ValidationErrors, depth int) {
+	n := float64(len(arr))
+	if min, ok := number(schema["minItems"]); ok && n < min {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("must have at least %v items", min)})
+	}
+	if max, ok := number(schema["maxItems"]); ok && n > max {
+		*errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("must have at most %v items", max)})
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		for i, item := range arr {
+			s.validate(items, item, fmt.Sprintf("%s[%d]", path, i), errs, depth+1)
+		}
+	}
+}
+
+func (s *Schema) matches(schema map[string]interface{}, v interface{}, depth int) bool {
+	var errs ValidationErrors
+	s.validate(schema, v, "$", &errs, depth+1)
+	return len(errs) == 0
+}
+
+func (s *Schema) countMatches(schemas []interface{}, v interface{}, depth int) int {
+	n := 0
+	for _, sub := range schemas {
+		if m, ok := sub.(map[string]interface{}); ok && s.matches(m, v, depth) {
+			n++
+		}
+	}
+	return n
+}
+
+// resolve follows a local JSON pointer such as "#/$defs/Address"
+func (s *Schema) resolve(ref string) (map[string]interface{}, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("only local $refs are supported")
+	}
+
+	var node interface{} = s.root
+	for _, part := range strings.Split(ref[2:], "/") {
+		part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("$ref %q not found", ref)
+		}
+		if node, ok = m[part]; !ok {
+			return nil, fmt.Errorf("$ref %q not found", ref)
+		}
+	}
+	target, ok := node.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("$ref %q is not a schema", ref)
+	}
+	return target, nil
+}
+
+func matchesType(t interface{}, v interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		return isType(t, v)
+	case []interface{}:
+		for _, name := range t {
+			if s, ok := name.(string); ok && isType(s, v) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func isType(name string, v interface{}) bool {
+	switch name {
+	case "object":
+		_, ok := v.(map[string]interface{})
+		return ok
+	case "array":
+		_, ok := v.([]interface{})
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	case "number":
+		_, ok := v.(json.Number)
+		return ok
+	case "integer":
+		n, ok := v.(json.Number)
+		if !ok {
+			return false
+		}
+		f, err := n.Float64()
+		return err == nil && f == math.Trunc(f)
+	}
+	return false
+}
+
+func typeOf(v interface{}) string {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	case json.Number:
+		if isType("integer", v) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func describeType(t interface{}) string {
+	if s, ok := t.(string); ok {
+		return s
+	}
+	return compactJSON(t)
+}
+
+// number reads a numeric schema keyword, which decodes as float64
+func number(v interface{}) (float64, bool) {
+	f, ok := v.(float64)
+	return f, ok
+}
+
+func containsValue(list []interface{}, v interface{}) bool {
+	for _, item := range list {
+		if equalValues(item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalValues compares a schema value with a document value by their JSON
+// encoding; numbers are compared numerically
+func equalValues(schemaValue, v interface{}) bool {
+	if n, ok := v.(json.Number); ok {
+		f, err := n.Float64()
+		sf, isNum := schemaValue.(float64)
+		return err == nil && isNum && f == sf
+	}
+	return compactJSON(schemaValue) == compactJSON(v)
+}
+
+func compactJSON(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
@@ -0,0 +1,31 @@
+package prompts
+
+// DefaultTemplate is the name of the builtin answer template
+const DefaultTemplate = "rag-answer"
+
+// builtin templates are registered in every new Registry; loading a file
+// with the same name and a higher version supersedes them
+var builtin = []Template{
+	{
+		Name:        DefaultTemplate,
+		Version:     "1",
+		Description: "Answer from retrieved context, admitting when it is missing",
+		Context: `{{if .Chunks}}Answer the question using only the context below. ` +
+			`If the context does not contain the answer, say so.
+
+Context:
+{{range .Chunks}}{{if $.Cite}}[{{.Index}}] {{end}}{{.Text}}
+
+{{end}}{{end}}`,
+		History: `{{if .History}}Conversation so far:
+{{range .History}}{{role .Role}}: {{trim .Content}}
+{{end}}
+{{end}}`,
+		Citations: `{{if .Chunks}}Cite the context passages that support each sentence by their number, ` +
+			`for example [1] or [2][3]. Only cite passages that exist.
+
+{{end}}`,
+		Question: `Question: {{.Question}}
+Answer:`,
+	},
+}
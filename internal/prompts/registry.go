@@ -0,0 +1,189 @@
+package prompts
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds prompt templates by name and version. It is safe for
+// concurrent use, so templates can be reloaded while queries run.
+type Registry struct {
+	mu        sync.RWMutex
+	templates map[string]map[string]*compiled
+}
+
+// NewRegistry returns a registry holding the builtin templates
+func NewRegistry() *Registry {
+	r := &Registry{templates: make(map[string]map[string]*compiled)}
+	for _, t := range builtin {
+		if err := r.Register(t); err != nil {
+			panic(err) // Builtins are fixed at compile time
+		}
+	}
+	return r
+}
+
+// Register parses and adds a template, replacing any existing template
+// with the same name and version
+func (r *Registry) Register(t Template) error {
+	c, err := compile(t)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.templates[t.Name] == nil {
+		r.templates[t.Name] = make(map[string]*compiled)
+	}
+	r.templates[t.Name][t.Version] = c
+	return nil
+}
+
+// Get returns a template by name. An empty version selects the latest one,
+// comparing dotted versions numerically ("v1.10" is newer than "v1.9").
+func (r *Registry) Get(name, version string) (Template, error) {
+	c, err := r.lookup(name, version)
+	if err != nil {
+		return Template{}, err
+	}
+	return c.Template, nil
+}
+
+// Versions lists a template's versions, oldest first
+func (r *Registry) Versions(name string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := make([]string, 0, len(r.templates[name]))
+	for v := range r.templates[name] {
+		versions = append(versions, v)
+	}
+	sort.Slice(versions, func(i, j int) bool { return compareVersions(versions[i], versions[j]) < 0 })
+	return versions
+}
+
+// Render executes a template with data
+func (r *Registry) Render(name, version string, data Data) (*Prompt, error) {
+	c, err := r.lookup(name, version)
+	if err != nil {
+		return nil, err
+	}
+	return c.render(data)
+}
+
+func (r *Registry) lookup(name, version string) (*compiled, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions, ok := r.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("prompt template %q not found", name)
+	}
+	if version != "" {
+		c, ok := versions[version]
+		if !ok {
+			return nil, fmt.Errorf("prompt template %q has no version %q", name, version)
+		}
+		return c, nil
+	}
+
+	var latest *compiled
+	for v, c := range versions {
+		if latest == nil || compareVersions(v, latest.Version) > 0 {
+			latest = c
+		}
+	}
+	return latest, nil
+}
+
+// LoadDir registers every *.json file in dir. A file holds either one
+// template object or an array of them. Nothing is registered if any file
+// is invalid.
+func (r *Registry) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("failed to list prompt templates: %w", err)
+	}
+	sort.Strings(paths)
+
+	var loaded []Template
+	for _, path := range paths {
+		templates, err := readTemplates(path)
+		if err != nil {
+			return err
+		}
+		for _, t := range templates {
+			if _, err := compile(t); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+		loaded = append(loaded, templates...)
+	}
+
+	for _, t := range loaded {
+		if err := r.Register(t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readTemplates(path string) ([]Template, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt template: %w", err)
+	}
+
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var templates []Template
+		if err := json.Unmarshal(data, &templates); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		return templates, nil
+	}
+
+	var t Template
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return []Template{t}, nil
+}
+
+// compareVersions orders dotted versions like "1", "v1.2" and "2.0.1",
+// comparing numeric segments as numbers and anything else as text
+func compareVersions(a, b string) int {
+	as := strings.Split(strings.TrimPrefix(a, "v"), ".")
+	bs := strings.Split(strings.TrimPrefix(b, "v"), ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var x, y string
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xerr := strconv.Atoi(x)
+		yn, yerr := strconv.Atoi(y)
+		switch {
+		case xerr == nil && yerr == nil:
+			if xn != yn {
+				if xn < yn {
+					return -1
+				}
+				return 1
+			}
+		case x != y:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
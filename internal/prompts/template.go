@@ -0,0 +1,153 @@
+package prompts
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// Template is a named, versioned prompt made of text/template parts. The
+// user message is Context, History, Citations and Question rendered in that
+// order and concatenated as-is, so each part controls its own spacing.
+type Template struct {
+	Name        string `json:"name"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+
+	// System becomes the model's system prompt (optional)
+	System string `json:"system,omitempty"`
+
+	// Context presents the retrieved chunks
+	Context string `json:"context,omitempty"`
+
+	// History presents earlier turns of the conversation
+	History string `json:"history,omitempty"`
+
+	// Citations tells the model how to cite sources; it is only rendered
+	// when Data.Cite is set
+	Citations string `json:"citations,omitempty"`
+
+	// Question asks the question itself
+	Question string `json:"question"`
+}
+
+// Data is what the template parts are executed with
+type Data struct {
+	Question string
+	Chunks   []Chunk
+	History  []Message
+	Cite     bool
+}
+
+// Chunk is one retrieved passage, numbered from 1 in prompt order
+type Chunk struct {
+	Index    int
+	Text     string
+	Metadata map[string]interface{}
+}
+
+// Message is one earlier turn of the conversation
+type Message struct {
+	Role    string // "user" or "assistant"
+	Content string
+}
+
+// Prompt is a rendered template
+type Prompt struct {
+	System string
+	User   string
+}
+
+// funcs are available to every template part
+var funcs = template.FuncMap{
+	"trim":  strings.TrimSpace,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"role": func(role string) string {
+		if role == "assistant" {
+			return "Assistant"
+		}
+		return "User"
+	},
+}
+
+// compiled holds a Template with its parsed parts
+type compiled struct {
+	Template
+	system, context, history, citations, question *template.Template
+}
+
+func compile(t Template) (*compiled, error) {
+	if t.Name == "" {
+		return nil, fmt.Errorf("prompt template has no name")
+	}
+	if t.Question == "" {
+		return nil, fmt.Errorf("prompt template %s has no question part", t.ID())
+	}
+
+	c := &compiled{Template: t}
+	parts := []struct {
+		name string
+		src  string
+		dst  **template.Template
+	}{
+		{"system", t.System, &c.system},
+		{"context", t.Context, &c.context},
+		{"history", t.History, &c.history},
+		{"citations", t.Citations, &c.citations},
+		{"question", t.Question, &c.question},
+	}
+	for _, p := range parts {
+		if p.src == "" {
+			continue
+		}
+		parsed, err := template.New(p.name).Funcs(funcs).Option("missingkey=error").Parse(p.src)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s part of prompt template %s: %w", p.name, t.ID(), err)
+		}
+		*p.dst = parsed
+	}
+	return c, nil
+}
+
+// ID identifies the template as "name@version"
+func (t Template) ID() string {
+	if t.Version == "" {
+		return t.Name
+	}
+	return t.Name + "@" + t.Version
+}
+
+func (c *compiled) render(data Data) (*Prompt, error) {
+	system, err := execute(c.system, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render prompt template %s: %w", c.ID(), err)
+	}
+
+	var user strings.Builder
+	parts := []*template.Template{c.context, c.history}
+	if data.Cite {
+		parts = append(parts, c.citations)
+	}
+	parts = append(parts, c.question)
+	for _, part := range parts {
+		text, err := execute(part, data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render prompt template %s: %w", c.ID(), err)
+		}
+		user.WriteString(text)
+	}
+
+	return &Prompt{System: strings.TrimSpace(system), User: user.String()}, nil
+}
+
+func execute(t *template.Template, data Data) (string, error) {
+	if t == nil {
+		return "", nil
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,52 @@
+package rag
+
+import "ragframework/internal/embedder"
+
+// Analyzer turns raw text into the terms used by keyword retrieval
+type Analyzer struct {
+	// Stem reduces words to their Porter stem ("indexing" → "index")
+	Stem bool `json:"stem"`
+
+	// Stopwords are dropped before stemming; nil keeps every token
+	Stopwords map[string]bool `json:"stopwords,omitempty"`
+
+	// MinLength drops tokens shorter than this many characters
+	MinLength int `json:"min_length,omitempty"`
+}
+
+// NewEnglishAnalyzer stems tokens and removes common English stopwords
+func NewEnglishAnalyzer() *Analyzer {
+	stopwords := make(map[string]bool, len(englishStopwords))
+	for _, w := range englishStopwords {
+		stopwords[w] = true
+	}
+	return &Analyzer{
+		Stem:      true,
+		Stopwords: stopwords,
+	}
+}
+
+// Analyze tokenizes text the same way as the sparse embedder, then applies
+// the stopword, length and stemming settings.
+func (a *Analyzer) Analyze(text string) []string {
+	tokens := embedder.Tokenize(text)
+	terms := tokens[:0]
+	for _, tok := range tokens {
+		if a.Stopwords[tok] || len([]rune(tok)) < a.MinLength {
+			continue
+		}
+		if a.Stem {
+			tok = porterStem(tok)
+		}
+		terms = append(terms, tok)
+	}
+	return terms
+}
+
+var englishStopwords = []string{
+	"a", "an", "and", "are", "as", "at", "be", "but", "by", "for", "if", "in",
+	"into", "is", "it", "no", "not", "of", "on", "or", "such", "that", "the",
+	"their", "then", "there", "these", "they", "this", "to", "was", "will",
+	"with", "what", "which", "who", "how", "when", "where", "do", "does",
+	"i", "you", "we", "can", "my", "our", "your",
+}
@@ -0,0 +1,236 @@
+package rag
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+)
+
+// BM25Retriever is an in-process inverted index scored with Okapi BM25.
+// It needs no vector store or embedding service, which makes it suited to
+// small corpora, offline tests and exact-identifier lookups.
+type BM25Retriever struct {
+	K1       float64   // Term frequency saturation (default 1.2)
+	B        float64   // Document length normalisation (default 0.75)
+	Analyzer *Analyzer // Tokenization applied to documents and queries
+
+	mu       sync.RWMutex
+	docs     map[string]*bm25Doc
+	postings map[string]map[string]int // term -> doc ID -> term frequency
+	totalLen int
+}
+
+type bm25Doc struct {
+	chunk  ContextChunk
+	terms  map[string]int
+	length int
+}
+
+// bm25Snapshot is the on-disk form written by Save
+type bm25Snapshot struct {
+	K1       float64
+	B        float64
+	Analyzer Analyzer
+	Docs     map[string]ContextChunk
+}
+
+// NewBM25Retriever creates an empty index. A nil analyzer uses
+// NewEnglishAnalyzer.
+func NewBM25Retriever(analyzer *Analyzer) *BM25Retriever {
+	if analyzer == nil {
+		analyzer = NewEnglishAnalyzer()
+	}
+	return &BM25Retriever{
+		K1:       1.2,
+		B:        0.75,
+		Analyzer: analyzer,
+		docs:     make(map[string]*bm25Doc),
+		postings: make(map[string]map[string]int),
+	}
+}
+
+// Add indexes a chunk under id, replacing any chunk already stored there
+func (r *BM25Retriever) Add(id string, chunk ContextChunk) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.remove(id)
+
+	terms := make(map[string]int)
+	analyzed := r.Analyzer.Analyze(chunk.Text)
+	for _, t := range analyzed {
+		terms[t]++
+	}
+	for t, tf := range terms {
+		if r.postings[t] == nil {
+			r.postings[t] = make(map[string]int)
+		}
+		r.postings[t][id] = tf
+	}
+
+	r.docs[id] = &bm25Doc{chunk: chunk, terms: terms, length: len(analyzed)}
+	r.totalLen += len(analyzed)
+}
+
+// Delete removes the chunk stored under id, if any
+func (r *BM25Retriever) Delete(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.remove(id)
+}
+
+// Len returns the number of indexed chunks
+func (r *BM25Retriever) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.docs)
+}
+
+func (r *BM25Retriever) remove(id string) {
+	doc, ok := r.docs[id]
+	if !ok {
+		return
+	}
+	for t := range doc.terms {
+		delete(r.postings[t], id)
+		if len(r.postings[t]) == 0 {
+			delete(r.postings, t)
+		}
+	}
+	r.totalLen -= doc.length
+	delete(r.docs, id)
+}
+
+// Retrieve scores every chunk sharing a term with the query. Filters are
+// matched against chunk metadata; ScoreThreshold applies to the raw BM25
+// score, which is not bounded to 0.0–1.0.
+func (r *BM25Retriever) Retrieve(ctx context.Context, query string, opts *RetrieveOptions) ([]ContextChunk, error) {
+	topK := 5
+	var threshold float64
+	var filters map[string]interface{}
+	if opts != nil {
+		if opts.TopK > 0 {
+			topK = opts.TopK
+		}
+		threshold = opts.ScoreThreshold
+		filters = opts.Filters
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.docs) == 0 {
+		return nil, nil
+	}
+
+	n := float64(len(r.docs))
+	avgLen := float64(r.totalLen) / n
+	scores := make(map[string]float64)
+	for _, term := range r.Analyzer.Analyze(query) {
+		postings := r.postings[term]
+		if len(postings) == 0 {
+			continue
+		}
+		df := float64(len(postings))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		for id, tf := range postings {
+			docLen := float64(r.docs[id].length)
+			f := float64(tf)
+			scores[id] += idf * f * (r.K1 + 1) / (f + r.K1*(1-r.B+r.B*docLen/avgLen))
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(scores))
+	for id, score := range scores {
+		if score < threshold {
+			continue
+		}
+		if len(filters) > 0 && !matchesFilters(r.docs[id].chunk.Metadata, filters) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if scores[ids[i]] != scores[ids[j]] {
+			return scores[ids[i]] > scores[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+	if len(ids) > topK {
+		ids = ids[:topK]
+	}
+
+	chunks := make([]ContextChunk, 0, len(ids))
+	for _, id := range ids {
+		chunks = append(chunks, withScore(r.docs[id].chunk, id, scores[id]))
+	}
+	return chunks, nil
+}
+
+func (r *BM25Retriever) RetrieveStream(ctx context.Context, query string, opts *RetrieveOptions, onChunk func(ContextChunk)) error {
+	chunks, err := r.Retrieve(ctx, query, opts)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			onChunk(chunk)
+		}
+	}
+	return nil
+}
+
+// Save writes the indexed chunks and settings to path. The inverted index
+// itself is rebuilt on load, so snapshots stay valid across analyzer fixes.
+func (r *BM25Retriever) Save(path string) error {
+	r.mu.RLock()
+	snap := bm25Snapshot{
+		K1:       r.K1,
+		B:        r.B,
+		Analyzer: *r.Analyzer,
+		Docs:     make(map[string]ContextChunk, len(r.docs)),
+	}
+	for id, doc := range r.docs {
+		snap.Docs[id] = doc.chunk
+	}
+	r.mu.RUnlock()
+
+	return writeSnapshot(path, &snap)
+}
+
+// LoadBM25Retriever restores an index written by Save
+func LoadBM25Retriever(path string) (*BM25Retriever, error) {
+	var snap bm25Snapshot
+	if err := readSnapshot(path, &snap); err != nil {
+		return nil, err
+	}
+
+	analyzer := snap.Analyzer
+	r := NewBM25Retriever(&analyzer)
+	r.K1, r.B = snap.K1, snap.B
+	for id, chunk := range snap.Docs {
+		r.Add(id, chunk)
+	}
+	return r, nil
+}
+
+// withScore copies chunk with its ID and score recorded in the metadata,
+// leaving the stored chunk untouched.
+func withScore(chunk ContextChunk, id string, score float64) ContextChunk {
+	metadata := make(map[string]interface{}, len(chunk.Metadata)+2)
+	for k, v := range chunk.Metadata {
+		metadata[k] = v
+	}
+	metadata["id"] = id
+	metadata["score"] = score
+	chunk.Metadata = metadata
+	return chunk
+}
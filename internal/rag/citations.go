@@ -0,0 +1,147 @@
+package rag
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Citation links a sentence of the answer to the context chunk it cites
+type Citation struct {
+	// Index is the cited passage number n from "[n]", counting from 1
+	// in QueryResult.Contexts order
+	Index int `json:"index"`
+
+	// Start and End are the byte offsets of the cited sentence in the
+	// answer
+	Start int `json:"start"`
+	End   int `json:"end"`
+
+	// Sentence is the cited sentence with its citation markers removed
+	Sentence string `json:"sentence"`
+
+	// Source and Page come from the cited chunk's metadata
+	Source string `json:"source,omitempty"`
+	Page   int    `json:"page,omitempty"`
+
+	// Valid is false when the model cited a passage that doesn't exist
+	Valid bool `json:"valid"`
+}
+
+// citationPattern matches "[1]" and grouped forms like "[1, 3]"
+var citationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// citationStripPattern also takes the space before a marker, so
+// "in 2019 [2]." reads "in 2019."
+var citationStripPattern = regexp.MustCompile(`\s*` + citationPattern.String())
+
+// ParseCitations extracts the [n] citations from an answer. A marker cites
+// the sentence it appears in, or the sentence just before it when it
+// follows the closing punctuation ("... in 2019. [2]"). Repeated citations
+// of the same passage by one sentence are reported once.
+func ParseCitations(answer string, contexts []ContextChunk) []Citation {
+	markers := citationPattern.FindAllStringSubmatchIndex(answer, -1)
+	if len(markers) == 0 {
+		return nil
+	}
+
+	var citations []Citation
+	seen := make(map[[2]int]bool)
+	var start, end, prevMarkerEnd int
+	for i, m := range markers {
+		// Adjacent markers like "[1][2]" share the previous marker's sentence
+		if i == 0 || strings.TrimSpace(answer[prevMarkerEnd:m[0]]) != "" {
+			start, end = citedSentence(answer, m[0])
+		}
+		prevMarkerEnd = m[1]
+
+		for _, field := range strings.Split(answer[m[2]:m[3]], ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(field))
+			if err != nil || seen[[2]int{start, n}] {
+				continue
+			}
+			seen[[2]int{start, n}] = true
+
+			c := Citation{
+				Index:    n,
+				Start:    start,
+				End:      end,
+				Sentence: stripCitations(answer[start:end]),
+				Valid:    n >= 1 && n <= len(contexts),
+			}
+			if c.Valid {
+				metadata := contexts[n-1].Metadata
+				c.Source, _ = metadata["source"].(string)
+				if page, ok := toFloat(metadata["page"]); ok {
+					c.Page = int(page)
+				}
+			}
+			citations = append(citations, c)
+		}
+	}
+	return citations
+}
+
+// citedSentence returns the span of the sentence a marker at pos belongs to
+func citedSentence(text string, pos int) (int, int) {
+	before := strings.TrimRightFunc(text[:pos], unicode.IsSpace)
+	if n := len(before); n > 0 && isSentenceEnd(before[n-1]) {
+		return trimSentence(text, sentenceStart(text, n-1), n)
+	}
+	return trimSentence(text, sentenceStart(text, pos), sentenceEnd(text, pos))
+}
+
+// sentenceStart finds the start of the sentence containing pos
+func sentenceStart(text string, pos int) int {
+	for i := pos - 1; i >= 0; i-- {
+		if text[i] == '\n' {
+			return i + 1
+		}
+		if isSentenceEnd(text[i]) && i+1 < len(text) && i+1 <= pos && isSpaceByte(text[i+1]) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// sentenceEnd finds the end of the sentence containing pos, including its
+// closing punctuation
+func sentenceEnd(text string, pos int) int {
+	for i := pos; i < len(text); i++ {
+		if text[i] == '\n' {
+			return i
+		}
+		if isSentenceEnd(text[i]) && (i+1 == len(text) || isSpaceByte(text[i+1])) {
+			return i + 1
+		}
+	}
+	return len(text)
+}
+
+// trimSentence drops surrounding whitespace and any citation markers left
+// at the start by the previous sentence
+func trimSentence(text string, start, end int) (int, int) {
+	for {
+		for start < end && isSpaceByte(text[start]) {
+			start++
+		}
+		loc := citationPattern.FindStringIndex(text[start:end])
+		if loc == nil || loc[0] != 0 {
+			break
+		}
+		start += loc[1]
+	}
+	for end > start && isSpaceByte(text[end-1]) {
+		end--
+	}
+	return start, end
+}
+
+func stripCitations(sentence string) string {
+	return strings.Join(strings.Fields(citationStripPattern.ReplaceAllString(sentence, "")), " ")
+}
+
+func isSentenceEnd(b byte) bool { return b == '.' || b == '!' || b == '?' }
+
+func isSpaceByte(b byte) bool { return b == ' ' || b == '\t' || b == '\n' || b == '\r' }
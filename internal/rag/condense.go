@@ -0,0 +1,55 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// condenseQuestion rewrites a follow-up question into one that can be
+// understood without the conversation, e.g. "what about for v2?" becomes
+// "How do I configure retries in v2?". The last HistoryTurns messages are
+// shown to the Generator.
+func (p *Pipeline) condenseQuestion(ctx context.Context, history []ChatMessage, question string) (string, error) {
+	prompt := "Rewrite the follow-up question as a standalone question that can be understood " +
+		"without the conversation. Keep names, versions and identifiers exactly as written. " +
+		"If it is already standalone, return it unchanged. Respond with only the question.\n\n" +
+		"Conversation:\n" + formatHistory(p.recentHistory(history)) +
+		"\nFollow-up question: " + question + "\nStandalone question:"
+
+	result, err := p.Generator.Generate(ctx, prompt, GenerateOptions{MaxTokens: 128})
+	if err != nil {
+		return "", fmt.Errorf("question condensation failed: %w", err)
+	}
+
+	standalone := strings.Trim(strings.TrimSpace(result.Text), `"`)
+	if standalone == "" {
+		return question, nil
+	}
+	return standalone, nil
+}
+
+// recentHistory keeps the last HistoryTurns messages (default 6)
+func (p *Pipeline) recentHistory(history []ChatMessage) []ChatMessage {
+	turns := p.HistoryTurns
+	if turns <= 0 {
+		turns = 6
+	}
+	if len(history) > turns {
+		return history[len(history)-turns:]
+	}
+	return history
+}
+
+// formatHistory renders messages as "User: ..." / "Assistant: ..." lines
+func formatHistory(history []ChatMessage) string {
+	var sb strings.Builder
+	for _, m := range history {
+		role := "User"
+		if m.Role == "assistant" {
+			role = "Assistant"
+		}
+		fmt.Fprintf(&sb, "%s: %s\n", role, strings.TrimSpace(m.Content))
+	}
+	return sb.String()
+}
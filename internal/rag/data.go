@@ -79,6 +79,32 @@ type QueryResult struct {
 	// Contexts contains all retrieved chunks used
 	Contexts []ContextChunk `json:"contexts,omitempty"`
 
+	// Citations maps answer sentences to the Contexts they cite, when
+	// QueryOptions.Citations is set
+	Citations []Citation `json:"citations,omitempty"`
+
+	// DroppedContexts lists retrieved chunks left out of the prompt
+	// because they didn't fit the context budget
+	DroppedContexts []ContextChunk `json:"dropped_contexts,omitempty"`
+
+	// GeneratedQueries lists the query variants used for multi-query
+	// retrieval, original question first
+	GeneratedQueries []string `json:"generated_queries,omitempty"`
+
+	// HypotheticalDocument is the passage embedded for HyDE retrieval
+	HypotheticalDocument string `json:"hypothetical_document,omitempty"`
+
+	// StandaloneQuestion is the follow-up rewritten with its chat history,
+	// as used for retrieval
+	StandaloneQuestion string `json:"standalone_question,omitempty"`
+
+	// RewriteErrors reports query-rewriting stages that failed, keyed
+	// "condense", "multi_query" or "hyde"; retrieval went ahead without them
+	RewriteErrors map[string]string `json:"rewrite_errors,omitempty"`
+
+	// Prompt identifies the template used for the answer, as "name@version"
+	Prompt string `json:"prompt,omitempty"`
+
 	// Timestamp marks when generation completed
 	Timestamp time.Time `json:"timestamp"`
 
@@ -88,4 +114,11 @@ type QueryResult struct {
 	_agentExtensions map[string]interface{} `json:"-"`
 }
 
+// ChatMessage is one earlier turn of a conversation
+type ChatMessage struct {
+	// Role is "user" or "assistant"
+	Role string `json:"role"`
 
+	// Content is the message text
+	Content string `json:"content"`
+}
@@ -0,0 +1,69 @@
+package rag
+
+import (
+	"context"
+	"sync"
+)
+
+// DocStore looks up full documents, such as the parent sections of
+// small-to-big retrieval, by ID
+type DocStore interface {
+	// GetDocuments returns the documents found for ids; missing IDs are
+	// simply absent from the map
+	GetDocuments(ctx context.Context, ids []string) (map[string]ContextChunk, error)
+}
+
+// MemoryDocStore is an in-process DocStore with gob snapshot persistence
+type MemoryDocStore struct {
+	mu   sync.RWMutex
+	docs map[string]ContextChunk
+}
+
+func NewMemoryDocStore() *MemoryDocStore {
+	return &MemoryDocStore{
+		docs: make(map[string]ContextChunk),
+	}
+}
+
+// Put stores doc under id, replacing any previous document
+func (s *MemoryDocStore) Put(id string, doc ContextChunk) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.docs[id] = doc
+}
+
+// Delete removes the document stored under id, if any
+func (s *MemoryDocStore) Delete(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.docs, id)
+}
+
+func (s *MemoryDocStore) GetDocuments(ctx context.Context, ids []string) (map[string]ContextChunk, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := make(map[string]ContextChunk, len(ids))
+	for _, id := range ids {
+		if doc, ok := s.docs[id]; ok {
+			found[id] = doc
+		}
+	}
+	return found, nil
+}
+
+// Save writes every stored document to path
+func (s *MemoryDocStore) Save(path string) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return writeSnapshot(path, s.docs)
+}
+
+// LoadMemoryDocStore restores a store written by Save
+func LoadMemoryDocStore(path string) (*MemoryDocStore, error) {
+	s := NewMemoryDocStore()
+	if err := readSnapshot(path, &s.docs); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -0,0 +1,165 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// EnsembleSource is one backend queried by an EnsembleRetriever
+type EnsembleSource struct {
+	// Name identifies the source in result metadata and errors
+	Name string
+
+	Retriever Retriever
+
+	// Weight scales this source's contribution; nil means 1.0 and 0
+	// turns the source off
+	Weight *float64
+}
+
+func (s EnsembleSource) weight() float64 {
+	if s.Weight == nil {
+		return 1
+	}
+	return *s.Weight
+}
+
+// EnsembleRetriever queries several retrievers concurrently and fuses their
+// results. A failing source doesn't fail the query as long as another
+// source returns results; its error is reported in each chunk's metadata:
+// - "sources": ["qdrant", "bm25"] (sources that returned the chunk)
+// - "source_scores": {"qdrant": 0.82} (original per-source scores)
+// - "source_errors": {"weaviate": "connection refused"}
+type EnsembleRetriever struct {
+	Sources []EnsembleSource
+
+	// Fusion is "rrf" (default) or "weighted"
+	Fusion string
+
+	// RRFK is the rank offset for reciprocal rank fusion (default 60)
+	RRFK float64
+}
+
+func NewEnsembleRetriever(sources ...EnsembleSource) *EnsembleRetriever {
+	return &EnsembleRetriever{
+		Sources: sources,
+		Fusion:  FusionRRF,
+	}
+}
+
+func (er *EnsembleRetriever) Retrieve(ctx context.Context, query string, opts *RetrieveOptions) ([]ContextChunk, error) {
+	if len(er.Sources) == 0 {
+		return nil, fmt.Errorf("ensemble has no sources")
+	}
+	switch er.Fusion {
+	case "", FusionRRF, FusionWeighted:
+	default:
+		return nil, fmt.Errorf("unknown fusion method %q", er.Fusion)
+	}
+
+	topK := 5
+	if opts != nil && opts.TopK > 0 {
+		topK = opts.TopK
+	}
+
+	weights := make([]float64, len(er.Sources))
+	active := 0
+	for i, src := range er.Sources {
+		weights[i] = src.weight()
+		if weights[i] < 0 {
+			return nil, fmt.Errorf("ensemble source %q has negative weight %v", er.sourceName(i), weights[i])
+		}
+		if weights[i] > 0 {
+			active++
+		}
+	}
+	if active == 0 {
+		return nil, fmt.Errorf("ensemble has no sources with a positive weight")
+	}
+
+	lists := make([][]ContextChunk, len(er.Sources))
+	errs := make([]error, len(er.Sources))
+	var wg sync.WaitGroup
+	for i, src := range er.Sources {
+		if weights[i] == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, src EnsembleSource) {
+			defer wg.Done()
+			lists[i], errs[i] = src.Retriever.Retrieve(ctx, query, opts)
+		}(i, src)
+	}
+	wg.Wait()
+
+	sourceErrors := make(map[string]interface{})
+	for i := range er.Sources {
+		if errs[i] != nil {
+			sourceErrors[er.sourceName(i)] = errs[i].Error()
+		}
+	}
+	if len(sourceErrors) == active {
+		return nil, fmt.Errorf("all ensemble sources failed: %w", errors.Join(errs...))
+	}
+
+	fused := fuseRankings(lists, weights, er.Fusion, er.RRFK)
+	if len(fused) == 0 && len(sourceErrors) > 0 {
+		// With nothing to attach them to, the errors are the only answer
+		return nil, fmt.Errorf("%d of %d ensemble sources failed and the rest found nothing: %w",
+			len(sourceErrors), active, errors.Join(errs...))
+	}
+	if len(fused) > topK {
+		fused = fused[:topK]
+	}
+
+	chunks := make([]ContextChunk, 0, len(fused))
+	for _, f := range fused {
+		metadata := make(map[string]interface{}, len(f.chunk.Metadata)+4)
+		for k, v := range f.chunk.Metadata {
+			metadata[k] = v
+		}
+
+		sources := make([]string, len(f.lists))
+		sourceScores := make(map[string]interface{}, len(f.lists))
+		for j, li := range f.lists {
+			sources[j] = er.sourceName(li)
+			sourceScores[sources[j]] = f.scores[j]
+		}
+		metadata["score"] = f.score
+		metadata["sources"] = sources
+		metadata["source_scores"] = sourceScores
+		if len(sourceErrors) > 0 {
+			metadata["source_errors"] = sourceErrors
+		}
+
+		chunk := f.chunk
+		chunk.Metadata = metadata
+		chunks = append(chunks, chunk)
+	}
+	return chunks, nil
+}
+
+func (er *EnsembleRetriever) RetrieveStream(ctx context.Context, query string, opts *RetrieveOptions, onChunk func(ContextChunk)) error {
+	chunks, err := er.Retrieve(ctx, query, opts)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			onChunk(chunk)
+		}
+	}
+	return nil
+}
+
+func (er *EnsembleRetriever) sourceName(i int) string {
+	if er.Sources[i].Name != "" {
+		return er.Sources[i].Name
+	}
+	return fmt.Sprintf("source-%d", i)
+}
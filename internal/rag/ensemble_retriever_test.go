@@ -0,0 +1,76 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// staticRetriever returns fixed chunks, or fails with err
+type staticRetriever struct {
+	texts []string
+	err   error
+}
+
+func (r staticRetriever) Retrieve(ctx context.Context, query string, opts *RetrieveOptions) ([]ContextChunk, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	chunks := make([]ContextChunk, len(r.texts))
+	for i, text := range r.texts {
+		chunks[i] = ContextChunk{Text: text}
+	}
+	return chunks, nil
+}
+
+func (r staticRetriever) RetrieveStream(ctx context.Context, query string, opts *RetrieveOptions, onChunk func(ContextChunk)) error {
+	return fmt.Errorf("not supported")
+}
+
+func TestEnsembleZeroWeightTurnsSourceOff(t *testing.T) {
+	zero := 0.0
+	er := NewEnsembleRetriever(
+		EnsembleSource{Name: "a", Retriever: staticRetriever{texts: []string{"a1", "a2"}}},
+		EnsembleSource{Name: "b", Retriever: staticRetriever{texts: []string{"b1"}}, Weight: &zero},
+	)
+	chunks, err := er.Retrieve(context.Background(), "q", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := chunkTexts(chunks); !slices.Equal(got, []string{"a1", "a2"}) {
+		t.Errorf("chunks = %v, want only source a", got)
+	}
+}
+
+func TestEnsembleNegativeWeight(t *testing.T) {
+	negative := -1.0
+	er := NewEnsembleRetriever(EnsembleSource{Name: "a", Retriever: staticRetriever{}, Weight: &negative})
+	if _, err := er.Retrieve(context.Background(), "q", nil); err == nil {
+		t.Error("negative weight accepted")
+	}
+}
+
+func TestEnsembleFailureWithNoResults(t *testing.T) {
+	down := errors.New("connection refused")
+	er := NewEnsembleRetriever(
+		EnsembleSource{Name: "a", Retriever: staticRetriever{}},
+		EnsembleSource{Name: "b", Retriever: staticRetriever{err: down}},
+	)
+	_, err := er.Retrieve(context.Background(), "q", nil)
+	if !errors.Is(err, down) {
+		t.Fatalf("err = %v, want the failing source's error", err)
+	}
+
+	// A failure next to a source with results is only reported
+	er.Sources[0].Retriever = staticRetriever{texts: []string{"a1"}}
+	chunks, err := er.Retrieve(context.Background(), "q", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chunks) != 1 || !strings.Contains(fmt.Sprint(chunks[0].Metadata["source_errors"]), "connection refused") {
+		t.Errorf("chunks = %+v, want source_errors", chunks)
+	}
+}
@@ -0,0 +1,89 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// matchesFilters applies RetrieveOptions.Filters to chunk metadata for the
+// in-process retrievers. Supported forms:
+// - "author": "John Doe" (equality)
+// - "lang": []string{"en", "de"} (any of)
+// - "date_after": "2023-01-01" / "page_before": 10 (range on "date" / "page")
+//
+// Every filter must match; a missing metadata key never matches.
+func matchesFilters(metadata map[string]interface{}, filters map[string]interface{}) bool {
+	for key, want := range filters {
+		switch {
+		case strings.HasSuffix(key, "_after"):
+			got, ok := metadata[strings.TrimSuffix(key, "_after")]
+			if !ok || compareValues(got, want) <= 0 {
+				return false
+			}
+		case strings.HasSuffix(key, "_before"):
+			got, ok := metadata[strings.TrimSuffix(key, "_before")]
+			if !ok || compareValues(got, want) >= 0 {
+				return false
+			}
+		default:
+			got, ok := metadata[key]
+			if !ok || !matchesAny(got, want) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matchesAny(got, want interface{}) bool {
+	switch options := want.(type) {
+	case []interface{}:
+		for _, o := range options {
+			if compareValues(got, o) == 0 {
+				return true
+			}
+		}
+		return false
+	case []string:
+		for _, o := range options {
+			if compareValues(got, o) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+	return compareValues(got, want) == 0
+}
+
+// compareValues orders numbers numerically and everything else by its
+// string form, which also gives the right order for ISO-8601 dates.
+func compareValues(a, b interface{}) int {
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,127 @@
+package rag
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// Fusion methods for combining ranked result lists
+const (
+	FusionRRF      = "rrf"      // Reciprocal rank fusion
+	FusionWeighted = "weighted" // Weighted sum of min-max normalised scores
+)
+
+// defaultRRFK is the rank offset from the original RRF paper
+const defaultRRFK = 60
+
+// fusedChunk is one deduplicated chunk after fusion
+type fusedChunk struct {
+	chunk  ContextChunk
+	score  float64
+	lists  []int     // Indices of the input lists that returned the chunk
+	scores []float64 // Original score from each of those lists
+}
+
+// fuseRankings merges ranked lists into a single list sorted by fused
+// score. Chunks with identical text (ignoring whitespace) are merged, keeping
+// the metadata of the first occurrence. Weights default to 1; lists with
+// weight 0 are left out.
+func fuseRankings(lists [][]ContextChunk, weights []float64, method string, rrfK float64) []fusedChunk {
+	if rrfK <= 0 {
+		rrfK = defaultRRFK
+	}
+
+	byKey := make(map[string]*fusedChunk)
+	var order []string
+	for li, list := range lists {
+		weight := 1.0
+		if li < len(weights) {
+			weight = weights[li]
+		}
+		if weight == 0 {
+			continue
+		}
+
+		var normalized []float64
+		if method == FusionWeighted {
+			normalized = normalizedChunkScores(list)
+		}
+
+		for rank, chunk := range list {
+			key := chunkKey(chunk)
+			entry, ok := byKey[key]
+			if !ok {
+				entry = &fusedChunk{chunk: chunk}
+				byKey[key] = entry
+				order = append(order, key)
+			} else if containsInt(entry.lists, li) {
+				continue // Same list returned the chunk twice; keep the better rank
+			}
+
+			if method == FusionWeighted {
+				entry.score += weight * normalized[rank]
+			} else {
+				entry.score += weight / (rrfK + float64(rank+1))
+			}
+			raw, _ := chunkScore(chunk)
+			entry.lists = append(entry.lists, li)
+			entry.scores = append(entry.scores, raw)
+		}
+	}
+
+	fused := make([]fusedChunk, 0, len(order))
+	for _, key := range order {
+		fused = append(fused, *byKey[key])
+	}
+	sort.SliceStable(fused, func(i, j int) bool { return fused[i].score > fused[j].score })
+	return fused
+}
+
+// chunkScore reads the relevance score a retriever attached to a chunk
+func chunkScore(chunk ContextChunk) (float64, bool) {
+	for _, key := range []string{"score", "certainty"} {
+		if v, ok := toFloat(chunk.Metadata[key]); ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// normalizedChunkScores min-max scales a list's scores into 0.0–1.0. Lists
+// without scores fall back to rank position.
+func normalizedChunkScores(list []ContextChunk) []float64 {
+	scores := make([]float64, len(list))
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for i, chunk := range list {
+		s, ok := chunkScore(chunk)
+		if !ok {
+			s = 1 / float64(i+1)
+		}
+		scores[i] = s
+		lo = math.Min(lo, s)
+		hi = math.Max(hi, s)
+	}
+	for i, s := range scores {
+		if hi == lo {
+			scores[i] = 1
+			continue
+		}
+		scores[i] = (s - lo) / (hi - lo)
+	}
+	return scores
+}
+
+// chunkKey identifies duplicate chunks by their whitespace-normalised text
+func chunkKey(chunk ContextChunk) string {
+	return strings.Join(strings.Fields(chunk.Text), " ")
+}
+
+func containsInt(list []int, v int) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
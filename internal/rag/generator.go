@@ -31,6 +31,10 @@ type GenerationChunk struct {
 	Delta string          `json:"delta"`            // Newly generated token(s)
 	IsLast bool           `json:"is_last"`          // Whether this is the final chunk
 	Raw    json.RawMessage `json:"raw,omitempty"`   // Full raw API response (optional)
+
+	// Set on the final chunk, as on GenerationResult
+	FinishReason string                 `json:"finish_reason,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
 	
 	// Reserved for future internal metadata / debugging
 	_agentExtensions map[string]interface{} `json:"-"`
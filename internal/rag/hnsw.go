@@ -0,0 +1,168 @@
+package rag
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// hnswGraph is a Hierarchical Navigable Small World index over node IDs.
+// Vectors live with the caller; the graph only sees them through distance
+// functions, where a lower distance means a closer match.
+type hnswGraph struct {
+	m              int // Links per node on upper layers
+	mMax0          int // Links per node on layer 0
+	efConstruction int
+	levelMult      float64
+
+	entry    int // -1 while the graph is empty
+	maxLevel int
+	links    [][][]int // node -> layer -> neighbours
+
+	rng      *rand.Rand
+	distance func(a, b int) float64
+}
+
+func newHNSWGraph(m, efConstruction int, distance func(a, b int) float64) *hnswGraph {
+	return &hnswGraph{
+		m:              m,
+		mMax0:          m * 2,
+		efConstruction: efConstruction,
+		levelMult:      1 / math.Log(float64(m)),
+		entry:          -1,
+		rng:            rand.New(rand.NewSource(42)),
+		distance:       distance,
+	}
+}
+
+// insert links node (which must be the next unused ID) into the graph
+func (g *hnswGraph) insert(node int) {
+	level := int(math.Floor(-math.Log(1-g.rng.Float64()) * g.levelMult))
+	g.links = append(g.links, make([][]int, level+1))
+
+	if g.entry < 0 {
+		g.entry, g.maxLevel = node, level
+		return
+	}
+
+	dist := func(other int) float64 { return g.distance(node, other) }
+	ep := g.entry
+	for l := g.maxLevel; l > level; l-- {
+		ep = g.searchLayer(dist, ep, 1, l)[0].node
+	}
+
+	for l := min(level, g.maxLevel); l >= 0; l-- {
+		candidates := g.searchLayer(dist, ep, g.efConstruction, l)
+		limit := g.m
+		if l == 0 {
+			limit = g.mMax0
+		}
+		neighbours := make([]int, 0, g.m)
+		for _, c := range candidates {
+			if len(neighbours) == g.m {
+				break
+			}
+			neighbours = append(neighbours, c.node)
+		}
+		g.links[node][l] = neighbours
+
+		for _, n := range neighbours {
+			g.links[n][l] = append(g.links[n][l], node)
+			if len(g.links[n][l]) > limit {
+				g.prune(n, l, limit)
+			}
+		}
+		ep = candidates[0].node
+	}
+
+	if level > g.maxLevel {
+		g.entry, g.maxLevel = node, level
+	}
+}
+
+// prune keeps only the limit closest neighbours of node on a layer
+func (g *hnswGraph) prune(node, layer, limit int) {
+	links := g.links[node][layer]
+	sort.Slice(links, func(i, j int) bool {
+		return g.distance(node, links[i]) < g.distance(node, links[j])
+	})
+	g.links[node][layer] = links[:limit]
+}
+
+// search returns up to ef nodes closest to the query, nearest first
+func (g *hnswGraph) search(dist func(node int) float64, ef int) []hnswCandidate {
+	if g.entry < 0 {
+		return nil
+	}
+	ep := g.entry
+	for l := g.maxLevel; l > 0; l-- {
+		ep = g.searchLayer(dist, ep, 1, l)[0].node
+	}
+	return g.searchLayer(dist, ep, ef, 0)
+}
+
+// searchLayer is the greedy beam search from the HNSW paper, returning the
+// ef closest nodes found on one layer sorted nearest first.
+func (g *hnswGraph) searchLayer(dist func(node int) float64, ep, ef, layer int) []hnswCandidate {
+	visited := map[int]bool{ep: true}
+	start := hnswCandidate{node: ep, dist: dist(ep)}
+
+	candidates := &hnswHeap{list: []hnswCandidate{start}}                   // Nearest on top
+	results := &hnswHeap{list: []hnswCandidate{start}, furthestFirst: true} // Furthest on top
+
+	for candidates.Len() > 0 {
+		c := heap.Pop(candidates).(hnswCandidate)
+		if c.dist > results.items()[0].dist && results.Len() >= ef {
+			break
+		}
+		for _, n := range g.links[c.node][layer] {
+			if visited[n] {
+				continue
+			}
+			visited[n] = true
+			d := dist(n)
+			if results.Len() < ef || d < results.items()[0].dist {
+				heap.Push(candidates, hnswCandidate{node: n, dist: d})
+				heap.Push(results, hnswCandidate{node: n, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := append([]hnswCandidate(nil), results.items()...)
+	sort.Slice(out, func(i, j int) bool { return out[i].dist < out[j].dist })
+	return out
+}
+
+type hnswCandidate struct {
+	node int
+	dist float64
+}
+
+// hnswHeap is a binary heap of candidates, nearest-first unless
+// furthestFirst is set.
+type hnswHeap struct {
+	list          []hnswCandidate
+	furthestFirst bool
+}
+
+func (h *hnswHeap) items() []hnswCandidate { return h.list }
+func (h *hnswHeap) Len() int               { return len(h.list) }
+func (h *hnswHeap) Swap(i, j int)          { h.list[i], h.list[j] = h.list[j], h.list[i] }
+func (h *hnswHeap) Push(x interface{})     { h.list = append(h.list, x.(hnswCandidate)) }
+
+func (h *hnswHeap) Less(i, j int) bool {
+	if h.furthestFirst {
+		return h.list[i].dist > h.list[j].dist
+	}
+	return h.list[i].dist < h.list[j].dist
+}
+
+func (h *hnswHeap) Pop() interface{} {
+	last := h.list[len(h.list)-1]
+	h.list = h.list[:len(h.list)-1]
+	return last
+}
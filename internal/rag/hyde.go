@@ -0,0 +1,81 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// hydeVector writes a hypothetical passage answering question and returns
+// the vector to search with: the passage's embedding, or its average with
+// the question's embedding in "average" mode. The passage doesn't need to
+// be correct; it only needs to look like the documents that are.
+func (p *Pipeline) hydeVector(ctx context.Context, question string, opts *HyDEOptions) ([]float32, string, error) {
+	mode := opts.Mode
+	if mode == "" {
+		mode = HyDEReplace
+	}
+	if mode != HyDEReplace && mode != HyDEAverage {
+		return nil, "", fmt.Errorf("unknown HyDE mode %q", opts.Mode)
+	}
+	maxTokens := opts.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = 256
+	}
+
+	prompt := "Write a short passage from a reference document that answers the question below. " +
+		"Write it as the document would, without mentioning the question.\n\n" +
+		"Question: " + question + "\nPassage:"
+	result, err := p.Generator.Generate(ctx, prompt, GenerateOptions{MaxTokens: maxTokens})
+	if err != nil {
+		return nil, "", fmt.Errorf("hypothetical document generation failed: %w", err)
+	}
+	passage := strings.TrimSpace(result.Text)
+	if passage == "" {
+		return nil, "", fmt.Errorf("hypothetical document generation returned no text")
+	}
+
+	vector, err := p.Embed(passage)
+	if err != nil {
+		return nil, "", fmt.Errorf("embedding failed: %w", err)
+	}
+	if mode == HyDEReplace {
+		return vector, passage, nil
+	}
+
+	queryVector, err := p.Embed(question)
+	if err != nil {
+		return nil, "", fmt.Errorf("embedding failed: %w", err)
+	}
+	if len(queryVector) != len(vector) {
+		return nil, "", fmt.Errorf("embedding dimensions differ: %d vs %d", len(queryVector), len(vector))
+	}
+	return averageVectors(vector, queryVector), passage, nil
+}
+
+// averageVectors returns the mean of two unit-normalised vectors, so
+// neither dominates because of its magnitude.
+func averageVectors(a, b []float32) []float32 {
+	na, nb := vectorNorm(a), vectorNorm(b)
+	out := make([]float32, len(a))
+	for i := range a {
+		var x, y float32
+		if na > 0 {
+			x = a[i] / na
+		}
+		if nb > 0 {
+			y = b[i] / nb
+		}
+		out[i] = (x + y) / 2
+	}
+	return out
+}
+
+func vectorNorm(v []float32) float32 {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	return float32(math.Sqrt(sum))
+}
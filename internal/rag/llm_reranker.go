@@ -0,0 +1,281 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// LLMReranker is a listwise reranker for deployments without a
+// cross-encoder: it shows the Generator numbered candidates and parses the
+// ranking it answers with. Candidates that don't fit one prompt are ranked
+// in batches, and the leaders of each batch are ranked again.
+type LLMReranker struct {
+	Generator Generator
+
+	// MaxPromptTokens caps each ranking prompt, measured with
+	// Generator.CountTokens (default 3000)
+	MaxPromptTokens int
+
+	// MaxPassageTokens truncates long candidates (default 300)
+	MaxPassageTokens int
+
+	// Options are passed to the Generator; a Temperature of 0 is
+	// recommended
+	Options GenerateOptions
+}
+
+func NewLLMReranker(generator Generator) *LLMReranker {
+	return &LLMReranker{
+		Generator:        generator,
+		MaxPromptTokens:  3000,
+		MaxPassageTokens: 300,
+	}
+}
+
+var rankNumberPattern = regexp.MustCompile(`\d+`)
+
+func (lr *LLMReranker) Rerank(ctx context.Context, query string, chunks []ContextChunk, topK int) ([]ContextChunk, error) {
+	if len(chunks) == 0 {
+		return chunks, nil
+	}
+	if topK <= 0 || topK > len(chunks) {
+		topK = len(chunks)
+	}
+
+	passages := make([]string, len(chunks))
+	for i, c := range chunks {
+		passages[i] = lr.truncate(c.Text)
+	}
+
+	all := make([]int, len(chunks))
+	for i := range all {
+		all[i] = i
+	}
+	order, err := lr.rank(ctx, query, passages, all, topK)
+	if err != nil {
+		return nil, err
+	}
+
+	reranked := make([]ContextChunk, 0, topK)
+	for pos, idx := range order[:topK] {
+		reranked = append(reranked, withUpdatedScore(chunks[idx], 1-float64(pos)/float64(len(order))))
+	}
+	return reranked, nil
+}
+
+// rank orders the candidate indices, best first. Candidates that need more
+// than one prompt are ranked per batch, and the batch rankings are merged
+// until the best `keep` are known; the rest follow in batch order.
+func (lr *LLMReranker) rank(ctx context.Context, query string, passages []string, candidates []int, keep int) ([]int, error) {
+	batches := lr.batch(query, passages, candidates)
+	if len(batches) == 1 {
+		return lr.rankBatch(ctx, query, passages, batches[0])
+	}
+
+	lists := make([][]int, 0, len(batches))
+	for _, b := range batches {
+		ranked, err := lr.rankBatch(ctx, query, passages, b)
+		if err != nil {
+			return nil, err
+		}
+		lists = append(lists, ranked)
+	}
+	top, rest, err := lr.merge(ctx, query, passages, lists, keep)
+	if err != nil {
+		return nil, err
+	}
+	return append(top, rest...), nil
+}
+
+// merge takes the best `keep` candidates from several ranked lists. Each
+// round ranks the heads of all lists in one prompt: when every list
+// contributes at least its top m, the round's top m are also the top m
+// overall. Lists too many for their heads to share a prompt are merged in
+// groups first.
+func (lr *LLMReranker) merge(ctx context.Context, query string, passages []string, lists [][]int, keep int) (top, rest []int, err error) {
+	for len(top) < keep {
+		lists = nonEmpty(lists)
+		if len(lists) == 0 {
+			break
+		}
+		if len(lists) == 1 {
+			n := min(len(lists[0]), keep-len(top))
+			top = append(top, lists[0][:n]...)
+			lists[0] = lists[0][n:]
+			break
+		}
+
+		// Interleave the lists by depth so the prompt takes an even
+		// prefix of each
+		var heads []int
+		for depth := 0; len(heads) < keep*len(lists); depth++ {
+			added := false
+			for _, l := range lists {
+				if depth < len(l) {
+					heads = append(heads, l[depth])
+					added = true
+				}
+			}
+			if !added {
+				break
+			}
+		}
+		round := lr.batch(query, passages, heads)[0]
+		// Listed in retrieval order, so passages the model leaves out
+		// keep the retriever's ranking
+		sort.Ints(round)
+
+		inRound := make(map[int]bool, len(round))
+		for _, idx := range round {
+			inRound[idx] = true
+		}
+		safe, covered := len(round), 0
+		for _, l := range lists {
+			n := 0
+			for n < len(l) && inRound[l[n]] {
+				n++
+			}
+			if n > 0 {
+				covered++
+			}
+			if n < len(l) {
+				safe = min(safe, n)
+			}
+		}
+
+		if safe == 0 {
+			var merged [][]int
+			for start := 0; start < len(lists); start += covered {
+				groupTop, groupRest, err := lr.merge(ctx, query, passages, lists[start:min(start+covered, len(lists))], keep-len(top))
+				if err != nil {
+					return nil, nil, err
+				}
+				merged = append(merged, groupTop)
+				rest = append(rest, groupRest...)
+			}
+			lists = merged
+			continue
+		}
+
+		ranked, err := lr.rankBatch(ctx, query, passages, round)
+		if err != nil {
+			return nil, nil, err
+		}
+		taken := make(map[int]bool)
+		for _, idx := range ranked[:min(safe, keep-len(top))] {
+			top = append(top, idx)
+			taken[idx] = true
+		}
+		for i, l := range lists {
+			remaining := make([]int, 0, len(l))
+			for _, idx := range l {
+				if !taken[idx] {
+					remaining = append(remaining, idx)
+				}
+			}
+			lists[i] = remaining
+		}
+	}
+
+	for _, l := range lists {
+		rest = append(rest, l...)
+	}
+	return top, rest, nil
+}
+
+func nonEmpty(lists [][]int) [][]int {
+	out := make([][]int, 0, len(lists))
+	for _, l := range lists {
+		if len(l) > 0 {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// batch splits candidates greedily so each ranking prompt stays within
+// MaxPromptTokens. A batch always holds at least two candidates so the
+// final round can make progress.
+func (lr *LLMReranker) batch(query string, passages []string, candidates []int) [][]int {
+	budget := lr.MaxPromptTokens
+	if budget <= 0 {
+		budget = 3000
+	}
+
+	var batches [][]int
+	var current []int
+	for _, idx := range candidates {
+		next := append(current[:len(current):len(current)], idx)
+		if len(current) >= 2 && lr.Generator.CountTokens(rankingPrompt(query, passages, next)) > budget {
+			batches = append(batches, current)
+			next = []int{idx}
+		}
+		current = next
+	}
+	return append(batches, current)
+}
+
+func (lr *LLMReranker) rankBatch(ctx context.Context, query string, passages []string, batch []int) ([]int, error) {
+	if len(batch) == 1 {
+		return batch, nil
+	}
+
+	opts := lr.Options
+	if opts.MaxTokens == 0 {
+		opts.MaxTokens = 8*len(batch) + 16
+	}
+	result, err := lr.Generator.Generate(ctx, rankingPrompt(query, passages, batch), opts)
+	if err != nil {
+		return nil, fmt.Errorf("llm rerank failed: %w", err)
+	}
+	return parseRanking(result.Text, batch), nil
+}
+
+// rankingPrompt numbers the batch's passages from 1
+func rankingPrompt(query string, passages []string, batch []int) string {
+	var sb strings.Builder
+	sb.WriteString("Rank the passages below by how well they answer the query.\n\n")
+	fmt.Fprintf(&sb, "Query: %s\n\n", query)
+	for i, idx := range batch {
+		fmt.Fprintf(&sb, "[%d] %s\n\n", i+1, passages[idx])
+	}
+	sb.WriteString("Respond only with the passage numbers from most to least relevant, ")
+	sb.WriteString("for example: [2] > [1] > [3]")
+	return sb.String()
+}
+
+// parseRanking reads passage numbers in the order the model listed them.
+// Out-of-range and repeated numbers are ignored, and passages the model
+// left out keep their original relative order at the end, so malformed
+// output degrades to the retriever's ranking instead of failing.
+func parseRanking(text string, batch []int) []int {
+	seen := make([]bool, len(batch))
+	order := make([]int, 0, len(batch))
+	for _, m := range rankNumberPattern.FindAllString(text, -1) {
+		n, err := strconv.Atoi(m)
+		if err != nil || n < 1 || n > len(batch) || seen[n-1] {
+			continue
+		}
+		seen[n-1] = true
+		order = append(order, batch[n-1])
+	}
+	for i, idx := range batch {
+		if !seen[i] {
+			order = append(order, idx)
+		}
+	}
+	return order
+}
+
+// truncate shortens text to roughly MaxPassageTokens
+func (lr *LLMReranker) truncate(text string) string {
+	limit := lr.MaxPassageTokens
+	if limit <= 0 {
+		limit = 300
+	}
+	return truncateToTokens(lr.Generator, text, limit)
+}
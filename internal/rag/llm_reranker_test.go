@@ -0,0 +1,166 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// passagePattern matches the numbered "pN" passages in a ranking prompt;
+// N is the passage's true relevance
+var passagePattern = regexp.MustCompile(`\[(\d+)\] p(\d+)`)
+
+// rankingStub answers ranking prompts by sorting passages on their
+// relevance, and charges 100 tokens per passage so batch sizes follow
+// MaxPromptTokens
+type rankingStub struct {
+	calls int
+
+	// answer overrides the reply when set
+	answer func(prompt string) string
+}
+
+func (s *rankingStub) Generate(ctx context.Context, prompt string, opts GenerateOptions) (*GenerationResult, error) {
+	s.calls++
+	if s.answer != nil {
+		return &GenerationResult{Text: s.answer(prompt)}, nil
+	}
+
+	type passage struct{ number, relevance int }
+	var passages []passage
+	for _, m := range passagePattern.FindAllStringSubmatch(prompt, -1) {
+		n, _ := strconv.Atoi(m[1])
+		r, _ := strconv.Atoi(m[2])
+		passages = append(passages, passage{n, r})
+	}
+	slices.SortFunc(passages, func(a, b passage) int { return b.relevance - a.relevance })
+
+	labels := make([]string, len(passages))
+	for i, p := range passages {
+		labels[i] = fmt.Sprintf("[%d]", p.number)
+	}
+	return &GenerationResult{Text: strings.Join(labels, " > ")}, nil
+}
+
+func (s *rankingStub) GenerateStream(ctx context.Context, prompt string, opts GenerateOptions, onChunk func(GenerationChunk)) error {
+	return fmt.Errorf("not supported")
+}
+
+func (s *rankingStub) CountTokens(text string) int {
+	return 100 * len(passagePattern.FindAllString(text, -1))
+}
+
+func passageChunks(relevance []int) []ContextChunk {
+	chunks := make([]ContextChunk, len(relevance))
+	for i, r := range relevance {
+		chunks[i] = ContextChunk{Text: fmt.Sprintf("p%d", r)}
+	}
+	return chunks
+}
+
+func chunkTexts(chunks []ContextChunk) []string {
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.Text
+	}
+	return texts
+}
+
+func TestLLMRerankerSingleBatch(t *testing.T) {
+	stub := &rankingStub{}
+	reranked, err := NewLLMReranker(stub).Rerank(context.Background(), "q", passageChunks([]int{3, 9, 1, 7}), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := chunkTexts(reranked), []string{"p9", "p7", "p3"}; !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+	if stub.calls != 1 {
+		t.Errorf("calls = %d, want 1", stub.calls)
+	}
+	for i := 1; i < len(reranked); i++ {
+		if reranked[i-1].Metadata["score"].(float64) <= reranked[i].Metadata["score"].(float64) {
+			t.Errorf("scores not descending at %d: %v", i, reranked)
+		}
+	}
+}
+
+// With batches of four and topK above the batch size, the leaders of
+// every batch must still be ranked against each other
+func TestLLMRerankerMergesBatches(t *testing.T) {
+	stub := &rankingStub{}
+	lr := NewLLMReranker(stub)
+	lr.MaxPromptTokens = 400
+
+	// Batch 1 holds the weakest passages, batch 2 the strongest
+	chunks := passageChunks([]int{4, 3, 2, 1, 98, 97, 96, 95, 50, 40})
+	reranked, err := lr.Rerank(context.Background(), "q", chunks, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := chunkTexts(reranked), []string{"p98", "p97", "p96", "p95", "p50"}; !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestLLMRerankerGlobalOrder(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 200; trial++ {
+		n := 2 + rng.Intn(40)
+		topK := 1 + rng.Intn(n)
+		budget := 200 + 100*rng.Intn(6)
+
+		relevance := rng.Perm(1000)[:n]
+		lr := NewLLMReranker(&rankingStub{})
+		lr.MaxPromptTokens = budget
+		reranked, err := lr.Rerank(context.Background(), "q", passageChunks(relevance), topK)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		slices.SortFunc(relevance, func(a, b int) int { return b - a })
+		want := chunkTexts(passageChunks(relevance[:topK]))
+		if got := chunkTexts(reranked); !slices.Equal(got, want) {
+			t.Fatalf("n=%d topK=%d budget=%d: order = %v, want %v", n, topK, budget, got, want)
+		}
+	}
+}
+
+// Unparseable output keeps the retriever's order instead of failing
+func TestLLMRerankerMalformedOutput(t *testing.T) {
+	stub := &rankingStub{answer: func(string) string { return "I can't rank these." }}
+	lr := NewLLMReranker(stub)
+	lr.MaxPromptTokens = 300
+
+	chunks := passageChunks([]int{1, 2, 3, 4, 5, 6, 7})
+	reranked, err := lr.Rerank(context.Background(), "q", chunks, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := chunkTexts(reranked), chunkTexts(chunks); !slices.Equal(got, want) {
+		t.Errorf("order = %v, want %v", got, want)
+	}
+}
+
+func TestParseRanking(t *testing.T) {
+	batch := []int{10, 11, 12, 13}
+	tests := []struct {
+		text string
+		want []int
+	}{
+		{"[3] > [1] > [4] > [2]", []int{12, 10, 13, 11}},
+		{"3, 1", []int{12, 10, 11, 13}},                        // Missing passages follow in order
+		{"[2] > [9] > [2] > [0] > [4]", []int{11, 13, 10, 12}}, // Out-of-range and repeats ignored
+		{"no ranking here", []int{10, 11, 12, 13}},
+	}
+	for _, tt := range tests {
+		if got := parseRanking(tt.text, batch); !slices.Equal(got, tt.want) {
+			t.Errorf("parseRanking(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
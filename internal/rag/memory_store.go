@@ -0,0 +1,362 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"ragframework/internal/embedder"
+	"sort"
+	"sync"
+)
+
+// Similarity metrics supported by MemoryStore
+const (
+	MetricCosine = "cosine"
+	MetricDot    = "dot"
+	MetricL2     = "l2"
+)
+
+// Index types supported by MemoryStore
+const (
+	IndexFlat = "flat" // Exact brute-force scan
+	IndexHNSW = "hnsw" // Approximate graph search
+)
+
+// MemoryStoreConfig configures an in-process vector store
+type MemoryStoreConfig struct {
+	// Metric is "cosine" (default), "dot" or "l2"
+	Metric string `json:"metric,omitempty"`
+
+	// Index is "flat" (default) or "hnsw"
+	Index string `json:"index,omitempty"`
+
+	// HNSW tuning; zero values use 16 / 200 / 64
+	M              int `json:"m,omitempty"`
+	EfConstruction int `json:"ef_construction,omitempty"`
+	EfSearch       int `json:"ef_search,omitempty"`
+}
+
+// MemoryStore is a pure-Go vector store implementing Retriever, so the
+// whole RAG flow can run without Qdrant or Weaviate. Query and document
+// vectors come from Embed, which defaults to the embeddings service.
+type MemoryStore struct {
+	// Embed turns text into a vector; swap it out for offline tests
+	Embed func(text string) ([]float32, error)
+
+	config MemoryStoreConfig
+
+	mu      sync.RWMutex
+	ids     []string       // node -> chunk ID
+	chunks  []ContextChunk // node -> stored chunk
+	vectors [][]float32    // node -> vector used for scoring
+	deleted []bool         // node -> tombstone
+	nodes   map[string]int // chunk ID -> live node
+	dim     int
+	graph   *hnswGraph
+}
+
+// memorySnapshot is the on-disk form written by Save
+type memorySnapshot struct {
+	Config MemoryStoreConfig
+	IDs    []string
+	Chunks []ContextChunk
+}
+
+func NewMemoryStore(cfg MemoryStoreConfig) (*MemoryStore, error) {
+	if cfg.Metric == "" {
+		cfg.Metric = MetricCosine
+	}
+	if cfg.Index == "" {
+		cfg.Index = IndexFlat
+	}
+	switch cfg.Metric {
+	case MetricCosine, MetricDot, MetricL2:
+	default:
+		return nil, fmt.Errorf("unknown metric %q", cfg.Metric)
+	}
+	if cfg.M <= 1 {
+		cfg.M = 16
+	}
+	if cfg.EfConstruction <= 0 {
+		cfg.EfConstruction = 200
+	}
+	if cfg.EfSearch <= 0 {
+		cfg.EfSearch = 64
+	}
+
+	s := &MemoryStore{
+		Embed:  embedder.EmbedText,
+		config: cfg,
+		nodes:  make(map[string]int),
+	}
+	switch cfg.Index {
+	case IndexFlat:
+	case IndexHNSW:
+		s.graph = newHNSWGraph(cfg.M, cfg.EfConstruction, func(a, b int) float64 {
+			return s.distance(s.vectors[a], s.vectors[b])
+		})
+	default:
+		return nil, fmt.Errorf("unknown index type %q", cfg.Index)
+	}
+	return s, nil
+}
+
+// Upsert stores chunk under id, replacing any previous chunk with that ID.
+// The chunk's Embedding is used when set, otherwise its Text is embedded.
+func (s *MemoryStore) Upsert(ctx context.Context, id string, chunk ContextChunk) error {
+	if len(chunk.Embedding) == 0 {
+		vector, err := s.Embed(chunk.Text)
+		if err != nil {
+			return fmt.Errorf("embedding failed: %w", err)
+		}
+		chunk.Embedding = vector
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.dim == 0 {
+		s.dim = len(chunk.Embedding)
+	} else if len(chunk.Embedding) != s.dim {
+		return fmt.Errorf("vector dimension %d does not match store dimension %d", len(chunk.Embedding), s.dim)
+	}
+
+	if node, ok := s.nodes[id]; ok {
+		if s.graph == nil {
+			s.chunks[node] = chunk
+			s.vectors[node] = s.prepare(chunk.Embedding)
+			return nil
+		}
+		// Graph links can't be rewired in place; retire the old node
+		s.deleted[node] = true
+	}
+
+	node := len(s.ids)
+	s.ids = append(s.ids, id)
+	s.chunks = append(s.chunks, chunk)
+	s.vectors = append(s.vectors, s.prepare(chunk.Embedding))
+	s.deleted = append(s.deleted, false)
+	s.nodes[id] = node
+	if s.graph != nil {
+		s.graph.insert(node)
+	}
+	return nil
+}
+
+// Delete removes the chunk stored under id and reports whether it existed
+func (s *MemoryStore) Delete(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	node, ok := s.nodes[id]
+	if !ok {
+		return false
+	}
+	s.deleted[node] = true
+	delete(s.nodes, id)
+	return true
+}
+
+// Len returns the number of live chunks
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.nodes)
+}
+
+func (s *MemoryStore) Retrieve(ctx context.Context, query string, opts *RetrieveOptions) ([]ContextChunk, error) {
+	if opts != nil && len(opts.Vector) > 0 {
+		return s.Search(ctx, opts.Vector, opts)
+	}
+	vector, err := s.Embed(query)
+	if err != nil {
+		return nil, fmt.Errorf("embedding failed: %w", err)
+	}
+	return s.Search(ctx, vector, opts)
+}
+
+// Search returns the chunks nearest to vector. With filters set the scan is
+// exact over the matching chunks, since pruning an approximate candidate
+// list after the fact can leave fewer than TopK results.
+func (s *MemoryStore) Search(ctx context.Context, vector []float32, opts *RetrieveOptions) ([]ContextChunk, error) {
+	topK := 5
+	var threshold float64
+	var filters map[string]interface{}
+	if opts != nil {
+		if opts.TopK > 0 {
+			topK = opts.TopK
+		}
+		threshold = opts.ScoreThreshold
+		filters = opts.Filters
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if len(s.nodes) == 0 {
+		return nil, nil
+	}
+	if len(vector) != s.dim {
+		return nil, fmt.Errorf("query dimension %d does not match store dimension %d", len(vector), s.dim)
+	}
+	q := s.prepare(vector)
+	fetchK := mmrFetchK(opts, topK)
+
+	var candidates []hnswCandidate
+	if s.graph != nil && len(filters) == 0 {
+		candidates = s.graph.search(func(node int) float64 {
+			return s.distance(q, s.vectors[node])
+		}, max(s.config.EfSearch, fetchK+len(s.ids)-len(s.nodes)))
+	} else {
+		for node := range s.ids {
+			if s.deleted[node] || (len(filters) > 0 && !matchesFilters(s.chunks[node].Metadata, filters)) {
+				continue
+			}
+			candidates = append(candidates, hnswCandidate{node: node, dist: s.distance(q, s.vectors[node])})
+		}
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].dist < candidates[j].dist })
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var chunks []ContextChunk
+	for _, c := range candidates {
+		if len(chunks) == fetchK {
+			break
+		}
+		if s.deleted[c.node] {
+			continue
+		}
+		score := s.score(c.dist)
+		if score < threshold {
+			continue
+		}
+		chunks = append(chunks, withScore(s.chunks[c.node], s.ids[c.node], score))
+	}
+
+	if opts != nil && opts.MMR != nil {
+		chunks = SelectMMR(vector, chunks, opts.MMR.lambda(), topK)
+	}
+	return chunks, nil
+}
+
+func (s *MemoryStore) RetrieveStream(ctx context.Context, query string, opts *RetrieveOptions, onChunk func(ContextChunk)) error {
+	chunks, err := s.Retrieve(ctx, query, opts)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			onChunk(chunk)
+		}
+	}
+	return nil
+}
+
+// Save writes the live chunks, with their embeddings, to path. Deleted
+// entries are dropped and any HNSW graph is rebuilt on load.
+func (s *MemoryStore) Save(path string) error {
+	s.mu.RLock()
+	snap := memorySnapshot{Config: s.config}
+	for node, id := range s.ids {
+		if s.deleted[node] {
+			continue
+		}
+		snap.IDs = append(snap.IDs, id)
+		snap.Chunks = append(snap.Chunks, s.chunks[node])
+	}
+	s.mu.RUnlock()
+
+	return writeSnapshot(path, &snap)
+}
+
+// LoadMemoryStore restores a store written by Save
+func LoadMemoryStore(path string) (*MemoryStore, error) {
+	var snap memorySnapshot
+	if err := readSnapshot(path, &snap); err != nil {
+		return nil, err
+	}
+
+	s, err := NewMemoryStore(snap.Config)
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range snap.IDs {
+		if err := s.Upsert(context.Background(), id, snap.Chunks[i]); err != nil {
+			return nil, fmt.Errorf("failed to restore %q: %w", id, err)
+		}
+	}
+	return s, nil
+}
+
+// prepare copies a vector into the form used for scoring; cosine vectors
+// are normalised once so search is a plain dot product.
+func (s *MemoryStore) prepare(v []float32) []float32 {
+	out := append([]float32(nil), v...)
+	if s.config.Metric != MetricCosine {
+		return out
+	}
+	var norm float64
+	for _, x := range out {
+		norm += float64(x) * float64(x)
+	}
+	if norm == 0 {
+		return out
+	}
+	inv := float32(1 / math.Sqrt(norm))
+	for i := range out {
+		out[i] *= inv
+	}
+	return out
+}
+
+// distance is lower-is-closer for every metric
+func (s *MemoryStore) distance(a, b []float32) float64 {
+	if s.config.Metric == MetricL2 {
+		var sum float64
+		for i := range a {
+			d := float64(a[i] - b[i])
+			sum += d * d
+		}
+		return sum
+	}
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return -dot
+}
+
+// score converts a distance back to higher-is-better: cosine similarity,
+// raw dot product, or 1/(1+d) for L2.
+func (s *MemoryStore) score(dist float64) float64 {
+	if s.config.Metric == MetricL2 {
+		return 1 / (1 + math.Sqrt(dist))
+	}
+	return -dist
+}
+
+// FetchChunks implements ChunkFetcher by scanning stored chunk metadata
+func (s *MemoryStore) FetchChunks(ctx context.Context, docID string, indices []int) ([]ContextChunk, error) {
+	want := make(map[int]bool, len(indices))
+	for _, i := range indices {
+		want[i] = true
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var chunks []ContextChunk
+	for _, node := range s.nodes {
+		doc, index, ok := chunkPosition(s.chunks[node])
+		if ok && doc == docID && want[index] {
+			chunks = append(chunks, s.chunks[node])
+		}
+	}
+	return chunks, nil
+}
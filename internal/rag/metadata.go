@@ -0,0 +1,10 @@
+package rag
+
+// Metadata keys written at ingestion and read back by the retrieval
+// post-processing steps
+const (
+	MetaDocID      = "doc_id"      // Source document identifier
+	MetaChunkIndex = "chunk_index" // Position of the chunk within its document
+	MetaParentID   = "parent_id"   // Parent section a child chunk belongs to
+	MetaParentText = "parent_text" // Parent section content stored on the child
+)
@@ -0,0 +1,77 @@
+package rag
+
+import "math"
+
+// SelectMMR picks k chunks by Maximal Marginal Relevance: each step takes
+// the chunk maximising
+//
+//	lambda*sim(query, chunk) - (1-lambda)*max sim(chunk, selected)
+//
+// using cosine similarity over ContextChunk.Embedding. Chunks without an
+// embedding can't be compared, so they compete on their position alone
+// and are never penalised for redundancy.
+func SelectMMR(query []float32, chunks []ContextChunk, lambda float64, k int) []ContextChunk {
+	if k <= 0 || k > len(chunks) {
+		k = len(chunks)
+	}
+
+	relevance := make([]float64, len(chunks))
+	for i, c := range chunks {
+		if len(c.Embedding) == len(query) && len(query) > 0 {
+			relevance[i] = cosineSimilarity(query, c.Embedding)
+		} else {
+			relevance[i] = 1 - float64(i)/float64(len(chunks))
+		}
+	}
+
+	// redundancy[i] tracks the max similarity of chunk i to the selection
+	redundancy := make([]float64, len(chunks))
+	used := make([]bool, len(chunks))
+	selected := make([]ContextChunk, 0, k)
+	for len(selected) < k {
+		best, bestScore := -1, math.Inf(-1)
+		for i := range chunks {
+			if used[i] {
+				continue
+			}
+			score := lambda*relevance[i] - (1-lambda)*redundancy[i]
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		used[best] = true
+		selected = append(selected, chunks[best])
+		for i, c := range chunks {
+			if used[i] || len(c.Embedding) == 0 || len(c.Embedding) != len(chunks[best].Embedding) {
+				continue
+			}
+			redundancy[i] = math.Max(redundancy[i], cosineSimilarity(c.Embedding, chunks[best].Embedding))
+		}
+	}
+	return selected
+}
+
+// mmrFetchK is the candidate pool size to request when MMR is enabled
+func mmrFetchK(opts *RetrieveOptions, topK int) int {
+	if opts == nil || opts.MMR == nil {
+		return topK
+	}
+	if opts.MMR.FetchK > topK {
+		return opts.MMR.FetchK
+	}
+	return topK * 4
+}
+
+func cosineSimilarity(a, b []float32) float64 {
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
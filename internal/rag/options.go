@@ -15,12 +15,64 @@ type RetrieveOptions struct {
 	// - "date_after": "2023-01-01"
 	Filters map[string]interface{} `json:"filters,omitempty"`
 
+	// Vector searches with a precomputed embedding instead of embedding
+	// the query text; keyword matching still uses the text (e.g. HyDE)
+	Vector []float32 `json:"vector,omitempty"`
+
+	// Hybrid blends vector search with keyword (BM25) matching
+	// Leave nil for pure vector search
+	Hybrid *HybridOptions `json:"hybrid,omitempty"`
+
+	// MMR re-selects results for diversity with Maximal Marginal Relevance
+	// Leave nil to keep pure relevance order
+	MMR *MMROptions `json:"mmr,omitempty"`
+
 	// Reserved for future agent-specific retrieval:
 	// - "verify_with_tool": true
 	// - "freshness_priority": 0.8
 	_agentExtensions map[string]interface{} `json:"-"`
 }
 
+// HybridOptions balances dense and keyword retrieval
+type HybridOptions struct {
+	// Alpha weights the two signals (0.0–1.0):
+	// - 1.0 = pure vector search
+	// - 0.5 = equal weight
+	// - 0.0 = pure keyword search
+	// nil means 0.5
+	Alpha *float64 `json:"alpha,omitempty"`
+}
+
+// alpha returns Alpha, defaulting to an equal weight
+func (o *HybridOptions) alpha() float64 {
+	if o.Alpha == nil {
+		return 0.5
+	}
+	return *o.Alpha
+}
+
+// MMROptions balances relevance against diversity among retrieved chunks
+type MMROptions struct {
+	// Lambda weights relevance against novelty (0.0–1.0):
+	// - 1.0 = pure relevance (no diversification)
+	// - 0.5 = common starting point
+	// - 0.0 = maximum diversity
+	// nil means 0.5
+	Lambda *float64 `json:"lambda,omitempty"`
+
+	// FetchK is the candidate pool size before selection
+	// Defaults to 4×TopK
+	FetchK int `json:"fetch_k,omitempty"`
+}
+
+// lambda returns Lambda, defaulting to 0.5
+func (o *MMROptions) lambda() float64 {
+	if o.Lambda == nil {
+		return 0.5
+	}
+	return *o.Lambda
+}
+
 // GenerateOptions controls text generation behavior
 type GenerateOptions struct {
 	// Model specifies which LLM variant to use
@@ -31,11 +83,16 @@ type GenerateOptions struct {
 	// - 0.0 = deterministic
 	// - 1.0 = default
 	// - 2.0 = highly creative
-	Temperature float64 `json:"temperature,omitempty"`
+	// nil leaves the provider's default
+	Temperature *float64 `json:"temperature,omitempty"`
 
 	// MaxTokens sets hard limit on output length
 	MaxTokens int `json:"max_tokens,omitempty"`
 
+	// SystemPrompt is sent as the system message, for models that
+	// support one
+	SystemPrompt string `json:"system_prompt,omitempty"`
+
 	// StopSequences halt generation when encountered
 	// Useful for tool integration and structured outputs
 	StopSequences []string `json:"stop_sequences,omitempty"`
@@ -62,8 +119,75 @@ type QueryOptions struct {
 	// - Retrieved scores are below threshold
 	Hybrid bool `json:"hybrid,omitempty"`
 
+	// MultiQuery expands the question into LLM-written variants and fuses
+	// their retrieval results
+	MultiQuery *MultiQueryOptions `json:"multi_query,omitempty"`
+
+	// HyDE searches with the embedding of an LLM-written hypothetical
+	// answer instead of (or blended with) the question's
+	HyDE *HyDEOptions `json:"hyde,omitempty"`
+
+	// History holds earlier turns of the conversation, oldest first
+	// Follow-up questions are condensed into a standalone question
+	// for retrieval; the answer prompt keeps the original wording
+	History []ChatMessage `json:"history,omitempty"`
+
+	// ContextBudget packs retrieved chunks into the prompt, most relevant
+	// first, until a share of the model's context window is used
+	// Leave nil to include every retrieved chunk
+	ContextBudget *ContextBudgetOptions `json:"context_budget,omitempty"`
+
+	// Citations numbers the context passages in the prompt, asks the model
+	// to cite them as [n] and parses the citations into the result
+	Citations bool `json:"citations,omitempty"`
+
+	// PromptTemplate selects the answer template from the pipeline's
+	// prompt registry (default "rag-answer")
+	PromptTemplate string `json:"prompt_template,omitempty"`
+
+	// PromptVersion pins a template version; empty uses the latest
+	PromptVersion string `json:"prompt_version,omitempty"`
+
 	// Reserved for future agent orchestration:
 	// - "max_retrieval_rounds": 2
 	// - "tool_injection_seq": 3
 	_agentExtensions map[string]interface{} `json:"-"`
 }
+
+// MultiQueryOptions configures query expansion before retrieval
+type MultiQueryOptions struct {
+	// Count is how many variants to generate in addition to the
+	// original question (default 3)
+	Count int `json:"count,omitempty"`
+
+	// Fusion merges the per-query results: "rrf" (default) or "weighted"
+	Fusion string `json:"fusion,omitempty"`
+}
+
+// HyDE modes
+const (
+	HyDEReplace = "replace" // Search with the hypothetical passage's embedding
+	HyDEAverage = "average" // Average it with the question's embedding
+)
+
+// HyDEOptions configures hypothetical document embeddings
+type HyDEOptions struct {
+	// Mode is "replace" (default) or "average"
+	Mode string `json:"mode,omitempty"`
+
+	// MaxTokens caps the hypothetical passage length (default 256)
+	MaxTokens int `json:"max_tokens,omitempty"`
+}
+
+// ContextBudgetOptions limits how much of the prompt retrieved context may use
+type ContextBudgetOptions struct {
+	// ContextWindow is the model's context size in tokens (default 8192)
+	ContextWindow int `json:"context_window,omitempty"`
+
+	// Share is the fraction of the window given to context (default 0.5)
+	Share float64 `json:"share,omitempty"`
+
+	// Truncate cuts the first chunk that doesn't fit down to the remaining
+	// budget instead of dropping it
+	Truncate bool `json:"truncate,omitempty"`
+}
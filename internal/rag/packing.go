@@ -0,0 +1,66 @@
+package rag
+
+// minTruncatedTokens is the smallest remainder worth filling with a
+// truncated chunk; anything shorter is dropped instead
+const minTruncatedTokens = 32
+
+// packContexts keeps chunks, in the order given, while their token count
+// (per Generator.CountTokens) fits the budget. With Truncate set, the first
+// chunk that doesn't fit is cut to the remaining budget and marked with
+// "truncated" metadata. Everything after is returned as dropped.
+func packContexts(gen Generator, chunks []ContextChunk, opts *ContextBudgetOptions) (kept, dropped []ContextChunk) {
+	window := opts.ContextWindow
+	if window <= 0 {
+		window = 8192
+	}
+	share := opts.Share
+	if share <= 0 || share > 1 {
+		share = 0.5
+	}
+	remaining := int(float64(window) * share)
+
+	for i, c := range chunks {
+		// The separator added by the default template counts against the budget too
+		cost := gen.CountTokens(c.Text + "\n\n")
+		if cost <= remaining {
+			kept = append(kept, c)
+			remaining -= cost
+			continue
+		}
+
+		if opts.Truncate && remaining >= minTruncatedTokens {
+			cut := c
+			cut.Text = truncateToTokens(gen, c.Text, remaining-gen.CountTokens("\n\n"))
+			metadata := make(map[string]interface{}, len(c.Metadata)+1)
+			for k, v := range c.Metadata {
+				metadata[k] = v
+			}
+			metadata["truncated"] = true
+			cut.Metadata = metadata
+			kept = append(kept, cut)
+			i++
+		}
+		return kept, chunks[i:]
+	}
+	return kept, nil
+}
+
+// truncateToTokens shortens text to at most limit tokens, cutting on a
+// rune boundary found by binary search over CountTokens
+func truncateToTokens(gen Generator, text string, limit int) string {
+	if gen.CountTokens(text) <= limit {
+		return text
+	}
+
+	runes := []rune(text)
+	lo, hi := 0, len(runes)
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if gen.CountTokens(string(runes[:mid])+"…") <= limit {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return string(runes[:lo]) + "…"
+}
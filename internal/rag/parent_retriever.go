@@ -0,0 +1,152 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+)
+
+// ParentDocumentRetriever implements small-to-big retrieval: it searches
+// small child chunks for precise matching, then returns the larger parent
+// sections they belong to. Parent text is read from the child's
+// "parent_text" metadata when the vector store payload carries it, and from
+// Store otherwise. Children without a "parent_id" are returned as-is.
+type ParentDocumentRetriever struct {
+	Retriever Retriever
+
+	// Store resolves parent IDs when the payload has no parent text
+	Store DocStore
+
+	// ChildFetchFactor multiplies TopK when searching children, since
+	// several children often collapse into one parent (default 3)
+	ChildFetchFactor int
+}
+
+func NewParentDocumentRetriever(retriever Retriever, store DocStore) *ParentDocumentRetriever {
+	return &ParentDocumentRetriever{
+		Retriever:        retriever,
+		Store:            store,
+		ChildFetchFactor: 3,
+	}
+}
+
+func (pr *ParentDocumentRetriever) Retrieve(ctx context.Context, query string, opts *RetrieveOptions) ([]ContextChunk, error) {
+	childOpts := RetrieveOptions{}
+	if opts != nil {
+		childOpts = *opts
+	}
+	topK := childOpts.TopK
+	if topK <= 0 {
+		topK = 5
+	}
+	factor := pr.ChildFetchFactor
+	if factor <= 0 {
+		factor = 3
+	}
+	childOpts.TopK = topK * factor
+
+	children, err := pr.Retriever.Retrieve(ctx, query, &childOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group children by parent in rank order; the best child's position
+	// and score stand for the parent
+	type group struct {
+		parentID string
+		best     ContextChunk
+		matched  int
+	}
+	var groups []*group
+	byParent := make(map[string]*group)
+	for _, child := range children {
+		parentID, _ := child.Metadata[MetaParentID].(string)
+		if parentID == "" {
+			groups = append(groups, &group{best: child, matched: 1})
+			continue
+		}
+		if g, ok := byParent[parentID]; ok {
+			g.matched++
+			continue
+		}
+		g := &group{parentID: parentID, best: child, matched: 1}
+		byParent[parentID] = g
+		groups = append(groups, g)
+	}
+	if len(groups) > topK {
+		groups = groups[:topK]
+	}
+
+	var missing []string
+	for _, g := range groups {
+		if _, ok := g.best.Metadata[MetaParentText].(string); g.parentID != "" && !ok {
+			missing = append(missing, g.parentID)
+		}
+	}
+
+	stored := map[string]ContextChunk{}
+	if len(missing) > 0 {
+		if pr.Store == nil {
+			return nil, fmt.Errorf("parent %q has no stored text and no DocStore is configured", missing[0])
+		}
+		stored, err = pr.Store.GetDocuments(ctx, missing)
+		if err != nil {
+			return nil, fmt.Errorf("parent lookup failed: %w", err)
+		}
+	}
+
+	results := make([]ContextChunk, 0, len(groups))
+	for _, g := range groups {
+		if g.parentID == "" {
+			results = append(results, g.best)
+			continue
+		}
+		results = append(results, parentChunk(g.parentID, g.best, g.matched, stored))
+	}
+	return results, nil
+}
+
+// parentChunk builds the returned parent from the best-matching child: the
+// parent's own text and metadata where available, with the child's score.
+func parentChunk(parentID string, child ContextChunk, matched int, stored map[string]ContextChunk) ContextChunk {
+	metadata := make(map[string]interface{})
+	text, _ := child.Metadata[MetaParentText].(string)
+	if parent, ok := stored[parentID]; ok {
+		text = parent.Text
+		for k, v := range parent.Metadata {
+			metadata[k] = v
+		}
+	} else {
+		for k, v := range child.Metadata {
+			metadata[k] = v
+		}
+		delete(metadata, MetaParentText)
+		delete(metadata, MetaChunkIndex)
+	}
+	if text == "" {
+		// Parent vanished from the store; fall back to the child itself
+		text = child.Text
+	}
+
+	if score, ok := chunkScore(child); ok {
+		metadata["score"] = score
+	}
+	metadata[MetaParentID] = parentID
+	metadata["matched_children"] = matched
+	return ContextChunk{Text: text, Metadata: metadata}
+}
+
+func (pr *ParentDocumentRetriever) RetrieveStream(ctx context.Context, query string, opts *RetrieveOptions, onChunk func(ContextChunk)) error {
+	chunks, err := pr.Retrieve(ctx, query, opts)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			onChunk(chunk)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,272 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"ragframework/internal/embedder"
+	"ragframework/internal/prompts"
+	"sync"
+	"time"
+)
+
+// Pipeline runs the end-to-end RAG flow: retrieve, optionally rerank,
+// build the prompt and generate the answer.
+type Pipeline struct {
+	Retriever Retriever
+	Generator Generator
+
+	// Reranker re-scores an over-fetched candidate set (optional)
+	Reranker Reranker
+
+	// RerankFetchFactor multiplies TopK when over-fetching candidates for
+	// the reranker (default 4)
+	RerankFetchFactor int
+
+	// Embed turns text into a vector for strategies that search with
+	// their own embedding, such as HyDE
+	Embed func(text string) ([]float32, error)
+
+	// HistoryTurns limits how many recent chat messages are used when
+	// condensing follow-up questions (default 6)
+	HistoryTurns int
+
+	// Prompts holds the answer templates selected by
+	// QueryOptions.PromptTemplate
+	Prompts *prompts.Registry
+}
+
+func NewPipeline(retriever Retriever, generator Generator) *Pipeline {
+	return &Pipeline{
+		Retriever:         retriever,
+		Generator:         generator,
+		RerankFetchFactor: 4,
+		Embed:             embedder.EmbedText,
+		Prompts:           prompts.NewRegistry(),
+	}
+}
+
+// Query answers question using retrieved context
+func (p *Pipeline) Query(ctx context.Context, question string, opts *QueryOptions) (*QueryResult, error) {
+	if opts == nil {
+		opts = &QueryOptions{}
+	}
+	var genOpts GenerateOptions
+	if opts.Generate != nil {
+		genOpts = *opts.Generate
+	}
+
+	// Query rewriting only improves recall, so a failed stage is reported
+	// in RewriteErrors and retrieval goes ahead with the question as it is
+	rewriteErrors := make(map[string]string)
+
+	// Retrieval works on a standalone question; the answer prompt keeps
+	// the user's own wording alongside the conversation
+	searchQuestion := question
+	if len(opts.History) > 0 {
+		standalone, err := p.condenseQuestion(ctx, opts.History, question)
+		if err != nil {
+			rewriteErrors["condense"] = err.Error()
+		} else {
+			searchQuestion = standalone
+		}
+	}
+
+	queries := []string{searchQuestion}
+	fusion := FusionRRF
+	if opts.MultiQuery != nil {
+		variants, err := p.expandQuery(ctx, searchQuestion, opts.MultiQuery.Count)
+		if err != nil {
+			rewriteErrors["multi_query"] = err.Error()
+		}
+		queries = append(queries, variants...)
+		if opts.MultiQuery.Fusion != "" {
+			fusion = opts.MultiQuery.Fusion
+		}
+	}
+
+	retrieveOpts := opts.Retrieve
+	var hypothetical string
+	if opts.HyDE != nil {
+		vector, passage, err := p.hydeVector(ctx, searchQuestion, opts.HyDE)
+		if err != nil {
+			rewriteErrors["hyde"] = err.Error()
+		} else {
+			withVector := RetrieveOptions{}
+			if retrieveOpts != nil {
+				withVector = *retrieveOpts
+			}
+			withVector.Vector = vector
+			retrieveOpts = &withVector
+			hypothetical = passage
+		}
+	}
+
+	contexts, err := p.retrieve(ctx, searchQuestion, queries, fusion, retrieveOpts)
+	if err != nil {
+		if !opts.Hybrid {
+			return nil, err
+		}
+		// Hybrid mode answers from the model alone when retrieval fails
+		contexts = nil
+	}
+
+	var dropped []ContextChunk
+	if opts.ContextBudget != nil {
+		contexts, dropped = packContexts(p.Generator, contexts, opts.ContextBudget)
+	}
+
+	prompt, promptID, err := p.renderPrompt(question, p.recentHistory(opts.History), contexts, opts)
+	if err != nil {
+		return nil, err
+	}
+	if genOpts.SystemPrompt == "" {
+		genOpts.SystemPrompt = prompt.System
+	}
+
+	gen, err := p.Generator.Generate(ctx, prompt.User, genOpts)
+	if err != nil {
+		return nil, fmt.Errorf("generation failed: %w", err)
+	}
+
+	result := &QueryResult{
+		Answer:               gen.Text,
+		Contexts:             contexts,
+		DroppedContexts:      dropped,
+		HypotheticalDocument: hypothetical,
+		Prompt:               promptID,
+		Timestamp:            time.Now(),
+	}
+	if opts.Citations {
+		result.Citations = ParseCitations(gen.Text, contexts)
+	}
+	if searchQuestion != question {
+		result.StandaloneQuestion = searchQuestion
+	}
+	if len(queries) > 1 {
+		result.GeneratedQueries = queries
+	}
+	if len(rewriteErrors) > 0 {
+		result.RewriteErrors = rewriteErrors
+	}
+	return result, nil
+}
+
+// retrieve fetches context for the question. Multiple queries are run
+// concurrently and fused. With a reranker configured it over-fetches
+// TopK×RerankFetchFactor candidates and keeps the best TopK, scored against
+// the original question.
+func (p *Pipeline) retrieve(ctx context.Context, question string, queries []string, fusion string, opts *RetrieveOptions) ([]ContextChunk, error) {
+	fetch := RetrieveOptions{}
+	if opts != nil {
+		fetch = *opts
+	}
+	topK := fetch.TopK
+	if topK <= 0 {
+		topK = 5
+	}
+	fetch.TopK = topK
+	if p.Reranker != nil {
+		factor := p.RerankFetchFactor
+		if factor <= 0 {
+			factor = 4
+		}
+		fetch.TopK = topK * factor
+	}
+
+	candidates, err := p.fetch(ctx, queries, fusion, &fetch)
+	if err != nil {
+		return nil, fmt.Errorf("retrieval failed: %w", err)
+	}
+	if p.Reranker == nil {
+		return candidates, nil
+	}
+
+	reranked, err := p.Reranker.Rerank(ctx, question, candidates, topK)
+	if err != nil {
+		return nil, fmt.Errorf("rerank failed: %w", err)
+	}
+	return reranked, nil
+}
+
+// fetch runs each query through the Retriever and fuses the result lists.
+// Queries that fail are skipped as long as at least one succeeds. A
+// precomputed Vector belongs to the first (original) query only.
+func (p *Pipeline) fetch(ctx context.Context, queries []string, fusion string, opts *RetrieveOptions) ([]ContextChunk, error) {
+	if len(queries) == 1 {
+		return p.Retriever.Retrieve(ctx, queries[0], opts)
+	}
+
+	lists := make([][]ContextChunk, len(queries))
+	errs := make([]error, len(queries))
+	var wg sync.WaitGroup
+	for i, q := range queries {
+		wg.Add(1)
+		queryOpts := opts
+		if i > 0 && len(opts.Vector) > 0 {
+			variant := *opts
+			variant.Vector = nil
+			queryOpts = &variant
+		}
+		go func(i int, q string, opts *RetrieveOptions) {
+			defer wg.Done()
+			lists[i], errs[i] = p.Retriever.Retrieve(ctx, q, opts)
+		}(i, q, queryOpts)
+	}
+	wg.Wait()
+
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed == len(queries) {
+		return nil, errors.Join(errs...)
+	}
+
+	fused := fuseRankings(lists, nil, fusion, 0)
+	if len(fused) > opts.TopK {
+		fused = fused[:opts.TopK]
+	}
+	chunks := make([]ContextChunk, len(fused))
+	for i, f := range fused {
+		chunks[i] = withUpdatedScore(f.chunk, f.score)
+	}
+	return chunks, nil
+}
+
+// renderPrompt fills the selected answer template with the retrieved
+// context and any earlier conversation. It returns the template's ID
+// alongside the prompt.
+func (p *Pipeline) renderPrompt(question string, history []ChatMessage, contexts []ContextChunk, opts *QueryOptions) (*prompts.Prompt, string, error) {
+	registry := p.Prompts
+	if registry == nil {
+		registry = defaultPrompts
+	}
+	name := opts.PromptTemplate
+	if name == "" {
+		name = prompts.DefaultTemplate
+	}
+
+	tmpl, err := registry.Get(name, opts.PromptVersion)
+	if err != nil {
+		return nil, "", err
+	}
+	data := prompts.Data{Question: question, Cite: opts.Citations}
+	for i, c := range contexts {
+		data.Chunks = append(data.Chunks, prompts.Chunk{Index: i + 1, Text: c.Text, Metadata: c.Metadata})
+	}
+	for _, m := range history {
+		data.History = append(data.History, prompts.Message{Role: m.Role, Content: m.Content})
+	}
+
+	prompt, err := registry.Render(tmpl.Name, tmpl.Version, data)
+	if err != nil {
+		return nil, "", err
+	}
+	return prompt, tmpl.ID(), nil
+}
+
+// defaultPrompts serves pipelines built without NewPipeline
+var defaultPrompts = prompts.NewRegistry()
@@ -0,0 +1,64 @@
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// answerStub fails every prompt containing one of failOn and answers the
+// rest with "answer"
+type answerStub struct {
+	failOn  []string
+	prompts []string
+}
+
+func (s *answerStub) Generate(ctx context.Context, prompt string, opts GenerateOptions) (*GenerationResult, error) {
+	s.prompts = append(s.prompts, prompt)
+	for _, marker := range s.failOn {
+		if strings.Contains(prompt, marker) {
+			return nil, errors.New("model unavailable")
+		}
+	}
+	return &GenerationResult{Text: "answer"}, nil
+}
+
+func (s *answerStub) GenerateStream(ctx context.Context, prompt string, opts GenerateOptions, onChunk func(GenerationChunk)) error {
+	return fmt.Errorf("not supported")
+}
+
+func (s *answerStub) CountTokens(text string) int {
+	return len(text) / 4
+}
+
+// A failed rewriting stage is reported and retrieval uses the question
+func TestQueryRewriteErrors(t *testing.T) {
+	gen := &answerStub{failOn: []string{"search queries", "passage from a reference document", "standalone question"}}
+	p := NewPipeline(staticRetriever{texts: []string{"retrieved"}}, gen)
+	p.Embed = func(string) ([]float32, error) { return []float32{1}, nil }
+
+	result, err := p.Query(context.Background(), "what about v2?", &QueryOptions{
+		MultiQuery: &MultiQueryOptions{Count: 3},
+		HyDE:       &HyDEOptions{},
+		History:    []ChatMessage{{Role: "user", Content: "How do retries work?"}, {Role: "assistant", Content: "With backoff."}},
+	})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if result.Answer != "answer" {
+		t.Errorf("Answer = %q", result.Answer)
+	}
+	for _, stage := range []string{"condense", "multi_query", "hyde"} {
+		if !strings.Contains(result.RewriteErrors[stage], "model unavailable") {
+			t.Errorf("RewriteErrors[%q] = %q", stage, result.RewriteErrors[stage])
+		}
+	}
+	if result.StandaloneQuestion != "" || result.GeneratedQueries != nil || result.HypotheticalDocument != "" {
+		t.Errorf("failed stages left output: %+v", result)
+	}
+	if len(result.Contexts) != 1 {
+		t.Errorf("Contexts = %v", result.Contexts)
+	}
+}
@@ -6,13 +6,20 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"ragframework/internal/embedder"
+	"sort"
 )
 
 type QdrantRetriever struct {
 	Host       string // Expect just "localhost:6333"
 	Collection string
+
+	// VectorName selects a named dense vector; empty uses the default vector
+	VectorName string
+	// SparseVectorName is the sparse (keyword) vector used for hybrid search
+	SparseVectorName string
 }
 
 func NewQdrantRetriever(host string, collection string) *QdrantRetriever {
@@ -23,16 +30,27 @@ func NewQdrantRetriever(host string, collection string) *QdrantRetriever {
 }
 
 type searchRequest struct {
-	Vector      []float32 `json:"vector"`
-	TopK        int       `json:"limit"`
-	WithPayload bool      `json:"with_payload"`
+	Vector      interface{} `json:"vector"`
+	TopK        int         `json:"limit"`
+	WithPayload bool        `json:"with_payload"`
+	WithVector  interface{} `json:"with_vector,omitempty"`
+}
+
+// namedVector targets a named dense or sparse vector in the collection
+type namedVector struct {
+	Name   string      `json:"name"`
+	Vector interface{} `json:"vector"`
+}
+
+type searchResult struct {
+	ID      interface{}            `json:"id"`
+	Payload map[string]interface{} `json:"payload"`
+	Score   float64                `json:"score"`
+	Vector  json.RawMessage        `json:"vector,omitempty"`
 }
 
 type searchResponse struct {
-	Result []struct {
-		Payload map[string]interface{} `json:"payload"`
-		Score   float64                `json:"score"`
-	} `json:"result"`
+	Result []searchResult `json:"result"`
 }
 
 func (qr *QdrantRetriever) Retrieve(ctx context.Context, query string, opts *RetrieveOptions) ([]ContextChunk, error) {
@@ -41,16 +59,167 @@ func (qr *QdrantRetriever) Retrieve(ctx context.Context, query string, opts *Ret
 		topK = opts.TopK
 	}
 
-	embedding, err := embedder.EmbedText(query)
+	var embedding []float32
+	if opts != nil && len(opts.Vector) > 0 {
+		embedding = opts.Vector
+	} else {
+		var err error
+		embedding, err = embedder.EmbedText(query)
+		if err != nil {
+			return nil, fmt.Errorf("embedding failed: %w", err)
+		}
+	}
+
+	var dense interface{} = embedding
+	if qr.VectorName != "" {
+		dense = namedVector{Name: qr.VectorName, Vector: embedding}
+	}
+
+	// MMR needs a larger candidate pool and the stored vectors to compare
+	fetchK := mmrFetchK(opts, topK)
+	withVector := opts != nil && opts.MMR != nil
+
+	var results []searchResult
+	var err error
+	if opts != nil && opts.Hybrid != nil {
+		results, err = qr.hybridSearch(ctx, query, dense, fetchK, opts.Hybrid.alpha(), withVector)
+	} else {
+		results, err = qr.search(ctx, dense, fetchK, withVector)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("embedding failed: %w", err)
+		return nil, err
+	}
+
+	fmt.Printf("🧲 Retrieved %d results from Qdrant\n", len(results))
+	for i, res := range results {
+		fmt.Printf("Result %d:\n  Score: %.4f\n  Payload: %+v\n", i+1, res.Score, res.Payload)
+	}
+
+	var chunks []ContextChunk
+	for _, res := range results {
+		text, ok := res.Payload["text"].(string)
+		if !ok {
+			continue
+		}
+		// Everything else in the payload (doc_id, parent_id, page, ...)
+		// is passed through as metadata
+		metadata := map[string]interface{}{}
+		for k, v := range res.Payload {
+			if k != "text" {
+				metadata[k] = v
+			}
+		}
+		metadata["score"] = res.Score
+
+		chunks = append(chunks, ContextChunk{
+			Text:      text,
+			Metadata:  metadata,
+			Embedding: qr.decodeVector(res.Vector),
+		})
 	}
 
+	if withVector {
+		chunks = SelectMMR(embedding, chunks, opts.MMR.lambda(), topK)
+	}
+	return chunks, nil
+}
+
+// hybridSearch runs a dense and a sparse search and blends them with
+// relative score fusion: each list is min-max normalised, then combined as
+// alpha*dense + (1-alpha)*keyword.
+func (qr *QdrantRetriever) hybridSearch(ctx context.Context, query string, dense interface{}, topK int, alpha float64, withVector bool) ([]searchResult, error) {
+	if alpha < 0 || alpha > 1 {
+		return nil, fmt.Errorf("hybrid alpha must be between 0 and 1, got %v", alpha)
+	}
+	if qr.SparseVectorName == "" {
+		return nil, fmt.Errorf("hybrid search requires SparseVectorName to be set")
+	}
+
+	// Over-fetch both sides so points ranked just outside topK by one
+	// signal can still win on the combined score.
+	limit := topK * 2
+
+	var denseResults []searchResult
+	if alpha > 0 {
+		var err error
+		denseResults, err = qr.search(ctx, dense, limit, withVector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var sparseResults []searchResult
+	if sparse := embedder.SparseEmbed(query); alpha < 1 && len(sparse.Indices) > 0 {
+		var err error
+		sparseResults, err = qr.search(ctx, namedVector{Name: qr.SparseVectorName, Vector: sparse}, limit, withVector)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	fused := make(map[string]*searchResult)
+	var order []string
+	blend := func(results []searchResult, weight float64) {
+		for i, score := range normalizeScores(results) {
+			key := fmt.Sprint(results[i].ID)
+			entry, ok := fused[key]
+			if !ok {
+				entry = &searchResult{ID: results[i].ID, Payload: results[i].Payload, Vector: results[i].Vector}
+				fused[key] = entry
+				order = append(order, key)
+			}
+			entry.Score += weight * score
+		}
+	}
+	blend(denseResults, alpha)
+	blend(sparseResults, 1-alpha)
+
+	merged := make([]searchResult, 0, len(order))
+	for _, key := range order {
+		merged = append(merged, *fused[key])
+	}
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Score > merged[j].Score })
+	if len(merged) > topK {
+		merged = merged[:topK]
+	}
+	return merged, nil
+}
+
+// normalizeScores min-max scales result scores into 0.0–1.0
+func normalizeScores(results []searchResult) []float64 {
+	scores := make([]float64, len(results))
+	if len(results) == 0 {
+		return scores
+	}
+	lo, hi := results[0].Score, results[0].Score
+	for _, r := range results {
+		lo = math.Min(lo, r.Score)
+		hi = math.Max(hi, r.Score)
+	}
+	for i, r := range results {
+		if hi == lo {
+			scores[i] = 1
+			continue
+		}
+		scores[i] = (r.Score - lo) / (hi - lo)
+	}
+	return scores
+}
+
+func (qr *QdrantRetriever) search(ctx context.Context, vector interface{}, limit int, withVector bool) ([]searchResult, error) {
 	reqBody := searchRequest{
-		Vector:      embedding,
-		TopK:        topK,
+		Vector:      vector,
+		TopK:        limit,
 		WithPayload: true,
 	}
+	if withVector {
+		// Only the dense vector is needed; skip the sparse one if present
+		if qr.VectorName != "" {
+			reqBody.WithVector = []string{qr.VectorName}
+		} else {
+			reqBody.WithVector = true
+		}
+	}
 
 	var bodyBuffer bytes.Buffer
 	if err := json.NewEncoder(&bodyBuffer).Encode(reqBody); err != nil {
@@ -79,26 +248,106 @@ func (qr *QdrantRetriever) Retrieve(ctx context.Context, query string, opts *Ret
 	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
 		return nil, fmt.Errorf("decode failed: %w", err)
 	}
+	return parsed.Result, nil
+}
 
-	fmt.Printf("🧲 Retrieved %d results from Qdrant\n", len(parsed.Result))
-	for i, res := range parsed.Result {
-		fmt.Printf("Result %d:\n  Score: %.4f\n  Payload: %+v\n", i+1, res.Score, res.Payload)
+// decodeVector extracts the dense vector from a search hit, which Qdrant
+// returns bare for the default vector and keyed by name otherwise.
+func (qr *QdrantRetriever) decodeVector(raw json.RawMessage) []float32 {
+	if len(raw) == 0 {
+		return nil
+	}
+	var vector []float32
+	if qr.VectorName == "" && json.Unmarshal(raw, &vector) == nil {
+		return vector
+	}
+	// Collections mixing a default dense vector with sparse vectors return
+	// the default one under the empty name.
+	var named map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &named); err != nil {
+		return nil
+	}
+	if err := json.Unmarshal(named[qr.VectorName], &vector); err != nil {
+		return nil
+	}
+	return vector
+}
+
+type scrollResponse struct {
+	Result struct {
+		Points []searchResult `json:"points"`
+	} `json:"result"`
+}
+
+// FetchChunks implements ChunkFetcher with a filtered scroll over the
+// "doc_id" and "chunk_index" payload fields
+func (qr *QdrantRetriever) FetchChunks(ctx context.Context, docID string, indices []int) ([]ContextChunk, error) {
+	if len(indices) == 0 {
+		return nil, nil
+	}
+	lo, hi := indices[0], indices[0]
+	want := make(map[int]bool, len(indices))
+	for _, i := range indices {
+		lo, hi = min(lo, i), max(hi, i)
+		want[i] = true
+	}
+
+	reqBody := map[string]interface{}{
+		"filter": map[string]interface{}{
+			"must": []interface{}{
+				map[string]interface{}{"key": MetaDocID, "match": map[string]interface{}{"value": docID}},
+				map[string]interface{}{"key": MetaChunkIndex, "range": map[string]interface{}{"gte": lo, "lte": hi}},
+			},
+		},
+		"limit":        hi - lo + 1,
+		"with_payload": true,
+	}
+
+	var bodyBuffer bytes.Buffer
+	if err := json.NewEncoder(&bodyBuffer).Encode(reqBody); err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	url := fmt.Sprintf("http://%s/collections/%s/points/scroll", qr.Host, qr.Collection)
+	req, err := http.NewRequestWithContext(ctx, "POST", url, &bodyBuffer)
+	if err != nil {
+		return nil, fmt.Errorf("request creation failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("qdrant request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("qdrant returned status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	var parsed scrollResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return nil, fmt.Errorf("decode failed: %w", err)
 	}
 
 	var chunks []ContextChunk
-	for _, res := range parsed.Result {
-		text, ok := res.Payload["text"].(string)
+	for _, point := range parsed.Result.Points {
+		text, ok := point.Payload["text"].(string)
 		if !ok {
 			continue
 		}
-		chunks = append(chunks, ContextChunk{
-			Text: text,
-			Metadata: map[string]interface{}{
-				"score": res.Score,
-			},
-		})
+		metadata := map[string]interface{}{}
+		for k, v := range point.Payload {
+			if k != "text" {
+				metadata[k] = v
+			}
+		}
+		chunk := ContextChunk{Text: text, Metadata: metadata}
+		if _, index, ok := chunkPosition(chunk); ok && want[index] {
+			chunks = append(chunks, chunk)
+		}
 	}
-
 	return chunks, nil
 }
 
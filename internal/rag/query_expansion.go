@@ -0,0 +1,53 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// listMarkerPattern matches bullets and numbering such as "-", "*", "2." or "3)"
+var listMarkerPattern = regexp.MustCompile(`^\s*(?:[-*•]|\d+[.)])\s*`)
+
+// expandQuery asks the Generator for up to n alternative phrasings or
+// sub-questions of question (default 3).
+func (p *Pipeline) expandQuery(ctx context.Context, question string, n int) ([]string, error) {
+	if n <= 0 {
+		n = 3
+	}
+
+	prompt := fmt.Sprintf(
+		"Write %d different search queries that would help answer the question below. "+
+			"Rephrase it with different wording, and split it into sub-questions if it asks several things. "+
+			"Return one query per line with no numbering or extra text.\n\nQuestion: %s\nQueries:",
+		n, question,
+	)
+	temperature := 0.7
+	result, err := p.Generator.Generate(ctx, prompt, GenerateOptions{Temperature: &temperature})
+	if err != nil {
+		return nil, fmt.Errorf("query expansion failed: %w", err)
+	}
+	return parseQueryVariants(result.Text, question, n), nil
+}
+
+// parseQueryVariants reads one query per line, dropping list markers,
+// quotes, blank lines and repeats of the original question.
+func parseQueryVariants(text, question string, n int) []string {
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(question)): true}
+	var variants []string
+	for _, line := range strings.Split(text, "\n") {
+		line = listMarkerPattern.ReplaceAllString(line, "")
+		line = strings.Trim(strings.TrimSpace(line), `"'`)
+		key := strings.ToLower(line)
+		if line == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		variants = append(variants, line)
+		if len(variants) == n {
+			break
+		}
+	}
+	return variants
+}
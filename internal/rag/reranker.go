@@ -0,0 +1,26 @@
+package rag
+
+import "context"
+
+// Reranker reorders retrieved chunks by their relevance to the query
+type Reranker interface {
+	// Rerank returns the best topK chunks, most relevant first, with
+	// "score" replaced by the reranker's score and the retriever's
+	// original score kept under "retrieval_score".
+	Rerank(ctx context.Context, query string, chunks []ContextChunk, topK int) ([]ContextChunk, error)
+}
+
+// withUpdatedScore copies chunk with its score replaced by a later stage's
+// (reranking or fusion), keeping the retriever's score as "retrieval_score".
+func withUpdatedScore(chunk ContextChunk, score float64) ContextChunk {
+	metadata := make(map[string]interface{}, len(chunk.Metadata)+1)
+	for k, v := range chunk.Metadata {
+		metadata[k] = v
+	}
+	if original, ok := chunkScore(chunk); ok {
+		metadata["retrieval_score"] = original
+	}
+	metadata["score"] = score
+	chunk.Metadata = metadata
+	return chunk
+}
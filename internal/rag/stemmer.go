@@ -0,0 +1,208 @@
+package rag
+
+import "strings"
+
+// porterStem reduces an English word to its stem using the classic Porter
+// algorithm. Words that aren't plain lowercase ASCII are returned unchanged.
+func porterStem(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	w := []byte(word)
+	w = stemStep1a(w)
+	w = stemStep1b(w)
+	w = stemStep1c(w)
+	w = stemReplaceFirst(w, stemStep2Rules, func(stem []byte) bool { return stemMeasure(stem) > 0 })
+	w = stemReplaceFirst(w, stemStep3Rules, func(stem []byte) bool { return stemMeasure(stem) > 0 })
+	w = stemStep4(w)
+	w = stemStep5(w)
+	return string(w)
+}
+
+type stemRule struct {
+	suffix, replacement string
+}
+
+// Rules are ordered so that the longest matching suffix is found first;
+// Porter only ever considers that one suffix, even if its condition fails.
+var stemStep2Rules = []stemRule{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"abli", "able"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+}
+
+var stemStep3Rules = []stemRule{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+var stemStep4Suffixes = []string{
+	"al", "ance", "ence", "er", "ic", "able", "ible", "ant", "ement", "ment",
+	"ent", "ion", "ou", "ism", "ate", "iti", "ous", "ive", "ize",
+}
+
+func stemIsConsonant(w []byte, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !stemIsConsonant(w, i-1)
+	}
+	return true
+}
+
+// stemMeasure counts the VC sequences in w ("m" in Porter's paper)
+func stemMeasure(w []byte) int {
+	n, i := 0, 0
+	for i < len(w) && stemIsConsonant(w, i) {
+		i++
+	}
+	for i < len(w) {
+		for i < len(w) && !stemIsConsonant(w, i) {
+			i++
+		}
+		if i >= len(w) {
+			break
+		}
+		for i < len(w) && stemIsConsonant(w, i) {
+			i++
+		}
+		n++
+	}
+	return n
+}
+
+func stemHasVowel(w []byte) bool {
+	for i := range w {
+		if !stemIsConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+func stemEndsDoubleConsonant(w []byte) bool {
+	l := len(w)
+	return l >= 2 && w[l-1] == w[l-2] && stemIsConsonant(w, l-1)
+}
+
+// stemEndsCVC reports a consonant-vowel-consonant ending where the last
+// consonant is not w, x or y (e.g. "hop" but not "snow").
+func stemEndsCVC(w []byte) bool {
+	l := len(w)
+	if l < 3 || !stemIsConsonant(w, l-1) || stemIsConsonant(w, l-2) || !stemIsConsonant(w, l-3) {
+		return false
+	}
+	c := w[l-1]
+	return c != 'w' && c != 'x' && c != 'y'
+}
+
+func stemHasSuffix(w []byte, suffix string) bool {
+	return strings.HasSuffix(string(w), suffix)
+}
+
+// stemReplaceFirst applies the first rule whose suffix matches, provided
+// the remaining stem satisfies cond.
+func stemReplaceFirst(w []byte, rules []stemRule, cond func([]byte) bool) []byte {
+	for _, r := range rules {
+		if !stemHasSuffix(w, r.suffix) {
+			continue
+		}
+		stem := w[:len(w)-len(r.suffix)]
+		if cond(stem) {
+			return append(stem[:len(stem):len(stem)], r.replacement...)
+		}
+		return w
+	}
+	return w
+}
+
+func stemStep1a(w []byte) []byte {
+	switch {
+	case stemHasSuffix(w, "sses"), stemHasSuffix(w, "ies"):
+		return w[:len(w)-2]
+	case stemHasSuffix(w, "ss"):
+		return w
+	case stemHasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+func stemStep1b(w []byte) []byte {
+	if stemHasSuffix(w, "eed") {
+		if stemMeasure(w[:len(w)-3]) > 0 {
+			return w[:len(w)-1]
+		}
+		return w
+	}
+
+	var stem []byte
+	switch {
+	case stemHasSuffix(w, "ed") && stemHasVowel(w[:len(w)-2]):
+		stem = w[:len(w)-2]
+	case stemHasSuffix(w, "ing") && stemHasVowel(w[:len(w)-3]):
+		stem = w[:len(w)-3]
+	default:
+		return w
+	}
+
+	switch {
+	case stemHasSuffix(stem, "at"), stemHasSuffix(stem, "bl"), stemHasSuffix(stem, "iz"):
+		return append(stem[:len(stem):len(stem)], 'e')
+	case stemEndsDoubleConsonant(stem):
+		if c := stem[len(stem)-1]; c != 'l' && c != 's' && c != 'z' {
+			return stem[:len(stem)-1]
+		}
+	case stemMeasure(stem) == 1 && stemEndsCVC(stem):
+		return append(stem[:len(stem):len(stem)], 'e')
+	}
+	return stem
+}
+
+func stemStep1c(w []byte) []byte {
+	if stemHasSuffix(w, "y") && stemHasVowel(w[:len(w)-1]) {
+		out := append([]byte(nil), w...)
+		out[len(out)-1] = 'i'
+		return out
+	}
+	return w
+}
+
+func stemStep4(w []byte) []byte {
+	for _, suffix := range stemStep4Suffixes {
+		if !stemHasSuffix(w, suffix) {
+			continue
+		}
+		stem := w[:len(w)-len(suffix)]
+		if stemMeasure(stem) <= 1 {
+			return w
+		}
+		if suffix == "ion" && !stemHasSuffix(stem, "s") && !stemHasSuffix(stem, "t") {
+			return w
+		}
+		return stem
+	}
+	return w
+}
+
+func stemStep5(w []byte) []byte {
+	if stemHasSuffix(w, "e") {
+		stem := w[:len(w)-1]
+		if m := stemMeasure(stem); m > 1 || (m == 1 && !stemEndsCVC(stem)) {
+			w = stem
+		}
+	}
+	if stemMeasure(w) > 1 && stemEndsDoubleConsonant(w) && stemHasSuffix(w, "l") {
+		w = w[:len(w)-1]
+	}
+	return w
+}
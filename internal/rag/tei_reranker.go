@@ -0,0 +1,84 @@
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// TEIReranker scores chunks with a cross-encoder served by
+// text-embeddings-inference's /rerank endpoint.
+type TEIReranker struct {
+	Host string // e.g., "http://localhost:8083"
+}
+
+func NewTEIReranker(host string) *TEIReranker {
+	return &TEIReranker{
+		Host: host,
+	}
+}
+
+type rerankRequest struct {
+	Query    string   `json:"query"`
+	Texts    []string `json:"texts"`
+	Truncate bool     `json:"truncate"`
+}
+
+type rerankResult struct {
+	Index int     `json:"index"`
+	Score float64 `json:"score"`
+}
+
+func (tr *TEIReranker) Rerank(ctx context.Context, query string, chunks []ContextChunk, topK int) ([]ContextChunk, error) {
+	if len(chunks) == 0 {
+		return chunks, nil
+	}
+
+	texts := make([]string, len(chunks))
+	for i, c := range chunks {
+		texts[i] = c.Text
+	}
+
+	body, err := json.Marshal(rerankRequest{Query: query, Texts: texts, Truncate: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode rerank request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", tr.Host+"/rerank", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("request creation failed: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("rerank request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("reranker returned status code %d: %s", resp.StatusCode, string(body))
+	}
+
+	// TEI returns results already sorted by descending score
+	var results []rerankResult
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("decode failed: %w", err)
+	}
+
+	if topK <= 0 || topK > len(results) {
+		topK = len(results)
+	}
+	reranked := make([]ContextChunk, 0, topK)
+	for _, r := range results[:topK] {
+		if r.Index < 0 || r.Index >= len(chunks) {
+			return nil, fmt.Errorf("reranker returned out-of-range index %d", r.Index)
+		}
+		reranked = append(reranked, withUpdatedScore(chunks[r.Index], r.Score))
+	}
+	return reranked, nil
+}
@@ -3,16 +3,26 @@ package rag
 import (
 	"context"
 	"fmt"
+	"ragframework/internal/embedder"
+	"strconv"
 
 	"github.com/weaviate/weaviate-go-client/v4/weaviate"
+	"github.com/weaviate/weaviate-go-client/v4/weaviate/filters"
 	"github.com/weaviate/weaviate-go-client/v4/weaviate/graphql"
 )
 
 type WeaviateRetriever struct {
 	Client    *weaviate.Client
 	ClassName string
+
+	// Properties lists extra object properties returned as chunk metadata,
+	// e.g. ChunkProperties for documents ingested with parent/child chunks
+	Properties []string
 }
 
+// ChunkProperties are the metadata properties written by chunked ingestion
+var ChunkProperties = []string{MetaDocID, MetaChunkIndex, MetaParentID, MetaParentText}
+
 func NewWeaviateRetriever(host string, className string) (*WeaviateRetriever, error) {
 	cfg := weaviate.Config{
 		Host:   host,
@@ -36,26 +46,84 @@ func (wr *WeaviateRetriever) Retrieve(ctx context.Context, query string, opts *R
 		topK = opts.TopK
 	}
 
-	result, err := wr.Client.GraphQL().Get().
+	withVector := opts != nil && opts.MMR != nil
+	get := wr.Client.GraphQL().Get().
 		WithClassName(wr.ClassName).
-		WithFields(
-			graphql.Field{Name: "text"},
-			graphql.Field{
-				Name: "_additional",
-				Fields: []graphql.Field{
-					{Name: "certainty"},
-				},
-			},
-		). // 🟢 This period was missing
-		WithNearText(wr.Client.GraphQL().NearTextArgBuilder().
+		WithLimit(mmrFetchK(opts, topK))
+
+	// queryVector is only computed locally when something needs it,
+	// unless the caller supplied one
+	var queryVector []float32
+	if opts != nil && len(opts.Vector) > 0 {
+		queryVector = opts.Vector
+	}
+	embedQuery := func() error {
+		if queryVector != nil {
+			return nil
+		}
+		embedding, err := embedder.EmbedText(query)
+		if err != nil {
+			return fmt.Errorf("embedding failed: %w", err)
+		}
+		queryVector = embedding
+		return nil
+	}
+
+	scoreField := "certainty"
+	if opts != nil && opts.Hybrid != nil {
+		alpha := opts.Hybrid.alpha()
+		if alpha < 0 || alpha > 1 {
+			return nil, fmt.Errorf("hybrid alpha must be between 0 and 1, got %v", alpha)
+		}
+
+		// The class has no vectorizer module, so the dense half of the
+		// hybrid query needs the vector supplied explicitly.
+		if err := embedQuery(); err != nil {
+			return nil, err
+		}
+
+		scoreField = "score"
+		get = get.WithHybrid(wr.Client.GraphQL().HybridArgumentBuilder().
+			WithQuery(query).
+			WithVector(queryVector).
+			WithAlpha(float32(alpha)).
+			WithFusionType(graphql.RelativeScore),
+		)
+	} else if queryVector != nil {
+		get = get.WithNearVector(wr.Client.GraphQL().NearVectorArgBuilder().
+			WithVector(queryVector),
+		)
+	} else {
+		get = get.WithNearText(wr.Client.GraphQL().NearTextArgBuilder().
 			WithConcepts([]string{query}),
-		).
-		WithLimit(topK).
-		Do(ctx)
+		)
+	}
+
+	additionalFields := []graphql.Field{{Name: scoreField}}
+	if withVector {
+		additionalFields = append(additionalFields, graphql.Field{Name: "vector"})
+		if err := embedQuery(); err != nil {
+			return nil, err
+		}
+	}
+
+	fields := []graphql.Field{{Name: "text"}}
+	for _, prop := range wr.Properties {
+		fields = append(fields, graphql.Field{Name: prop})
+	}
+	fields = append(fields, graphql.Field{
+		Name:   "_additional",
+		Fields: additionalFields,
+	})
+
+	result, err := get.WithFields(fields...).Do(ctx)
 
 	if err != nil {
 		return nil, fmt.Errorf("weaviate query failed: %w", err)
 	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("weaviate query failed: %s", result.Errors[0].Message)
+	}
 
 	rawDocs, ok := result.Data["Get"].(map[string]interface{})[wr.ClassName].([]interface{})
 	if !ok {
@@ -67,16 +135,122 @@ func (wr *WeaviateRetriever) Retrieve(ctx context.Context, query string, opts *R
 		item := doc.(map[string]interface{})
 		additional := item["_additional"].(map[string]interface{})
 
+		metadata := map[string]interface{}{}
+		for _, prop := range wr.Properties {
+			if v, ok := item[prop]; ok && v != nil {
+				metadata[prop] = v
+			}
+		}
+		if scoreField == "score" {
+			// Hybrid scores come back as strings in the GraphQL response
+			if raw, ok := additional["score"].(string); ok {
+				if score, err := strconv.ParseFloat(raw, 64); err == nil {
+					metadata["score"] = score
+				}
+			}
+		} else {
+			metadata["certainty"] = additional["certainty"]
+		}
+
 		chunks = append(chunks, ContextChunk{
-			Text: item["text"].(string),
-			Metadata: map[string]interface{}{
-				"certainty": additional["certainty"],
-			},
+			Text:      item["text"].(string),
+			Metadata:  metadata,
+			Embedding: toFloat32Slice(additional["vector"]),
+		})
+	}
+
+	if withVector {
+		chunks = SelectMMR(queryVector, chunks, opts.MMR.lambda(), topK)
+	}
+	return chunks, nil
+}
+
+// FetchChunks implements ChunkFetcher with a where filter on the
+// "doc_id" and "chunk_index" properties; "chunk_index" must be declared
+// as int (see scripts.CreateSchema)
+func (wr *WeaviateRetriever) FetchChunks(ctx context.Context, docID string, indices []int) ([]ContextChunk, error) {
+	if len(indices) == 0 {
+		return nil, nil
+	}
+	lo, hi := indices[0], indices[0]
+	want := make(map[int]bool, len(indices))
+	for _, i := range indices {
+		lo, hi = min(lo, i), max(hi, i)
+		want[i] = true
+	}
+
+	where := filters.Where().
+		WithOperator(filters.And).
+		WithOperands([]*filters.WhereBuilder{
+			filters.Where().WithPath([]string{MetaDocID}).WithOperator(filters.Equal).WithValueText(docID),
+			filters.Where().WithPath([]string{MetaChunkIndex}).WithOperator(filters.GreaterThanEqual).WithValueInt(int64(lo)),
+			filters.Where().WithPath([]string{MetaChunkIndex}).WithOperator(filters.LessThanEqual).WithValueInt(int64(hi)),
 		})
+
+	fields := []graphql.Field{{Name: "text"}, {Name: MetaDocID}, {Name: MetaChunkIndex}}
+	for _, prop := range wr.Properties {
+		if prop != MetaDocID && prop != MetaChunkIndex {
+			fields = append(fields, graphql.Field{Name: prop})
+		}
+	}
+
+	result, err := wr.Client.GraphQL().Get().
+		WithClassName(wr.ClassName).
+		WithWhere(where).
+		WithLimit(hi - lo + 1).
+		WithFields(fields...).
+		Do(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("weaviate query failed: %w", err)
+	}
+	if len(result.Errors) > 0 {
+		return nil, fmt.Errorf("weaviate query failed: %s", result.Errors[0].Message)
+	}
+
+	rawDocs, ok := result.Data["Get"].(map[string]interface{})[wr.ClassName].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected format from weaviate response")
+	}
+
+	var chunks []ContextChunk
+	for _, doc := range rawDocs {
+		item := doc.(map[string]interface{})
+		text, ok := item["text"].(string)
+		if !ok {
+			continue
+		}
+		metadata := map[string]interface{}{}
+		for _, f := range fields[1:] {
+			if v, ok := item[f.Name]; ok && v != nil {
+				metadata[f.Name] = v
+			}
+		}
+		chunk := ContextChunk{Text: text, Metadata: metadata}
+		if _, index, ok := chunkPosition(chunk); ok && want[index] {
+			chunks = append(chunks, chunk)
+		}
 	}
 	return chunks, nil
 }
 
+// toFloat32Slice converts a JSON-decoded vector ([]interface{} of
+// float64) into []float32, returning nil for anything else.
+func toFloat32Slice(v interface{}) []float32 {
+	raw, ok := v.([]interface{})
+	if !ok {
+		return nil
+	}
+	out := make([]float32, 0, len(raw))
+	for _, x := range raw {
+		f, ok := x.(float64)
+		if !ok {
+			return nil
+		}
+		out = append(out, float32(f))
+	}
+	return out
+}
+
 func (wr *WeaviateRetriever) RetrieveStream(ctx context.Context, query string, opts *RetrieveOptions, onChunk func(ContextChunk)) error {
 	chunks, err := wr.Retrieve(ctx, query, opts)
 	if err != nil {
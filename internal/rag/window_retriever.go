@@ -0,0 +1,226 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ChunkFetcher loads chunks of one document by their position, using the
+// "doc_id" and "chunk_index" metadata written at ingestion
+type ChunkFetcher interface {
+	// FetchChunks returns the chunks found at indices; missing positions
+	// are simply left out
+	FetchChunks(ctx context.Context, docID string, indices []int) ([]ContextChunk, error)
+}
+
+// WindowRetriever expands each retrieved chunk with the Window chunks
+// before and after it in the same document, and merges overlapping or
+// touching windows into one contiguous passage. Chunks without document
+// position metadata are returned unchanged.
+//
+// Each passage keeps the best score and rank of the hits it contains, and
+// records its extent as "chunk_start" / "chunk_end" metadata.
+type WindowRetriever struct {
+	Retriever Retriever
+	Fetcher   ChunkFetcher
+
+	// Window is how many neighbours to add on each side (default 1)
+	Window int
+}
+
+func NewWindowRetriever(retriever Retriever, fetcher ChunkFetcher, window int) *WindowRetriever {
+	return &WindowRetriever{
+		Retriever: retriever,
+		Fetcher:   fetcher,
+		Window:    window,
+	}
+}
+
+// chunkWindow is a contiguous index range of one document
+type chunkWindow struct {
+	docID      string
+	start, end int // Inclusive
+	rank       int // Best rank of the hits inside
+	best       ContextChunk
+}
+
+func (wr *WindowRetriever) Retrieve(ctx context.Context, query string, opts *RetrieveOptions) ([]ContextChunk, error) {
+	hits, err := wr.Retriever.Retrieve(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+	window := wr.Window
+	if window <= 0 {
+		window = 1
+	}
+
+	// Positioned hits become windows; the rest pass straight through
+	var passthrough []chunkWindow
+	byDoc := make(map[string][]*chunkWindow)
+	known := make(map[string]map[int]ContextChunk)
+	for rank, hit := range hits {
+		docID, index, ok := chunkPosition(hit)
+		if !ok {
+			passthrough = append(passthrough, chunkWindow{rank: rank, best: hit})
+			continue
+		}
+		byDoc[docID] = append(byDoc[docID], &chunkWindow{
+			docID: docID,
+			start: max(index-window, 0),
+			end:   index + window,
+			rank:  rank,
+			best:  hit,
+		})
+		if known[docID] == nil {
+			known[docID] = make(map[int]ContextChunk)
+		}
+		known[docID][index] = hit
+	}
+
+	merged := passthrough
+	for docID, windows := range byDoc {
+		windows = mergeWindows(windows)
+
+		var need []int
+		for _, w := range windows {
+			for i := w.start; i <= w.end; i++ {
+				if _, ok := known[docID][i]; !ok {
+					need = append(need, i)
+				}
+			}
+		}
+		if len(need) > 0 {
+			neighbours, err := wr.Fetcher.FetchChunks(ctx, docID, need)
+			if err != nil {
+				return nil, fmt.Errorf("fetching neighbours of %q failed: %w", docID, err)
+			}
+			for _, n := range neighbours {
+				if _, index, ok := chunkPosition(n); ok {
+					if _, seen := known[docID][index]; !seen {
+						known[docID][index] = n
+					}
+				}
+			}
+		}
+
+		for _, w := range windows {
+			merged = append(merged, *w)
+		}
+	}
+
+	sort.Slice(merged, func(i, j int) bool { return merged[i].rank < merged[j].rank })
+	results := make([]ContextChunk, 0, len(merged))
+	for _, w := range merged {
+		if w.docID == "" {
+			results = append(results, w.best)
+			continue
+		}
+		results = append(results, windowPassage(w, known[w.docID]))
+	}
+	return results, nil
+}
+
+// mergeWindows sorts one document's windows and joins any that overlap or
+// touch, keeping the better-ranked hit of each merge
+func mergeWindows(windows []*chunkWindow) []*chunkWindow {
+	sort.Slice(windows, func(i, j int) bool { return windows[i].start < windows[j].start })
+	out := windows[:1]
+	for _, w := range windows[1:] {
+		last := out[len(out)-1]
+		if w.start > last.end+1 {
+			out = append(out, w)
+			continue
+		}
+		last.end = max(last.end, w.end)
+		if w.rank < last.rank {
+			last.rank, last.best = w.rank, w.best
+		}
+	}
+	return out
+}
+
+// windowPassage stitches the chunks of a window together in order,
+// trimming text repeated by chunk overlap
+func windowPassage(w chunkWindow, chunks map[int]ContextChunk) ContextChunk {
+	var text string
+	start, end := -1, -1
+	for i := w.start; i <= w.end; i++ {
+		c, ok := chunks[i]
+		if !ok {
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		end = i
+		text = joinOverlapping(text, c.Text)
+	}
+
+	metadata := make(map[string]interface{}, len(w.best.Metadata)+2)
+	for k, v := range w.best.Metadata {
+		metadata[k] = v
+	}
+	metadata["chunk_start"] = start
+	metadata["chunk_end"] = end
+	return ContextChunk{Text: text, Metadata: metadata}
+}
+
+// minOverlapWords keeps a single shared word like "the" from being
+// mistaken for chunk overlap
+const minOverlapWords = 3
+
+// joinOverlapping appends next to text, dropping the longest run of
+// leading words in next that repeats the end of text
+func joinOverlapping(text, next string) string {
+	if text == "" {
+		return next
+	}
+	a, b := strings.Fields(text), strings.Fields(next)
+	for n := min(len(a), len(b)); n >= minOverlapWords; n-- {
+		if equalWords(a[len(a)-n:], b[:n]) {
+			return strings.TrimSpace(text + " " + strings.Join(b[n:], " "))
+		}
+	}
+	return text + " " + next
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// chunkPosition reads a chunk's document ID and index from its metadata.
+// Indices may arrive as float64 after a JSON round trip.
+func chunkPosition(chunk ContextChunk) (string, int, bool) {
+	docID, ok := chunk.Metadata[MetaDocID].(string)
+	if !ok || docID == "" {
+		return "", 0, false
+	}
+	index, ok := toFloat(chunk.Metadata[MetaChunkIndex])
+	if !ok {
+		return "", 0, false
+	}
+	return docID, int(index), true
+}
+
+func (wr *WindowRetriever) RetrieveStream(ctx context.Context, query string, opts *RetrieveOptions, onChunk func(ContextChunk)) error {
+	chunks, err := wr.Retrieve(ctx, query, opts)
+	if err != nil {
+		return err
+	}
+	for _, chunk := range chunks {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+			onChunk(chunk)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package render
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// ANSI SGR codes
+const (
+	ansiBold      = "1"
+	ansiDim       = "2"
+	ansiItalic    = "3"
+	ansiUnderline = "4"
+	ansiStrike    = "9"
+	ansiCyan      = "36"
+	ansiYellow    = "33"
+)
+
+// ruleWidth is the width of horizontal rules
+const ruleWidth = 40
+
+var (
+	headingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletPattern   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	orderedPattern  = regexp.MustCompile(`^(\s*)(\d+)[.)]\s+(.*)$`)
+	quotePattern    = regexp.MustCompile(`^\s*>\s?(.*)$`)
+	rulePattern     = regexp.MustCompile(`^\s*([-*_])(\s*([-*_])){2,}\s*$`)
+	fencePattern    = regexp.MustCompile("^\\s*(```|~~~)\\s*(\\S*)")
+	tableSepPattern = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?\s*$`)
+	linkPattern     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)\)`)
+	boldPattern     = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	italicPattern   = regexp.MustCompile(`\*([^*\s][^*]*?)\*|(^|[^\w])_([^_\s][^_]*?)_([^\w]|$)`)
+	strikePattern   = regexp.MustCompile(`~~(.+?)~~`)
+	checkboxPattern = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+)
+
+// styler applies ANSI styles, or nothing in plain-text mode
+type styler struct {
+	ansi bool
+}
+
+func (s styler) style(text string, codes ...string) string {
+	if !s.ansi || text == "" {
+		return text
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m" + text + "\x1b[0m"
+}
+
+func render(text string, ansi bool) string {
+	s := styler{ansi: ansi}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	var out []string
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := fencePattern.FindStringSubmatch(line); m != nil {
+			var code []string
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), m[1]); i++ {
+				code = append(code, lines[i])
+			}
+			out = append(out, s.codeBlock(code, m[2])...)
+			continue
+		}
+
+		if isTableRow(line) && i+1 < len(lines) && tableSepPattern.MatchString(lines[i+1]) && strings.Contains(lines[i+1], "-") {
+			rows := [][]string{splitRow(line)}
+			for i += 2; i < len(lines) && isTableRow(lines[i]); i++ {
+				rows = append(rows, splitRow(lines[i]))
+			}
+			i--
+			out = append(out, s.table(rows)...)
+			continue
+		}
+
+		out = append(out, s.line(line))
+	}
+	return strings.Join(out, "\n")
+}
+
+// line renders one block-level line
+func (s styler) line(line string) string {
+	if m := headingPattern.FindStringSubmatch(line); m != nil {
+		text := s.inline(m[2])
+		switch len(m[1]) {
+		case 1:
+			return s.style(text, ansiBold, ansiUnderline, ansiCyan)
+		case 2:
+			return s.style(text, ansiBold, ansiCyan)
+		default:
+			return s.style(text, ansiBold)
+		}
+	}
+	if rulePattern.MatchString(line) {
+		if !s.ansi {
+			return strings.Repeat("-", ruleWidth)
+		}
+		return s.style(strings.Repeat("─", ruleWidth), ansiDim)
+	}
+	if m := bulletPattern.FindStringSubmatch(line); m != nil {
+		item := m[2]
+		marker := "•"
+		if !s.ansi {
+			marker = "-"
+		}
+		if c := checkboxPattern.FindStringSubmatch(item); c != nil && s.ansi {
+			marker = "☐"
+			if c[1] != " " {
+				marker = "☑"
+			}
+			item = c[2]
+		}
+		return m[1] + s.style(marker, ansiYellow) + " " + s.inline(item)
+	}
+	if m := orderedPattern.FindStringSubmatch(line); m != nil {
+		return m[1] + s.style(m[2]+".", ansiYellow) + " " + s.inline(m[3])
+	}
+	if m := quotePattern.FindStringSubmatch(line); m != nil {
+		if !s.ansi {
+			return "  " + s.inline(m[1])
+		}
+		return s.style("│ ", ansiDim) + s.style(s.inline(m[1]), ansiItalic)
+	}
+	return s.inline(line)
+}
+
+// codeBlock indents code and labels it with its language
+func (s styler) codeBlock(code []string, lang string) []string {
+	var out []string
+	if lang != "" && s.ansi {
+		out = append(out, s.style("  "+lang, ansiDim))
+	}
+	for _, c := range code {
+		out = append(out, "    "+s.style(c, ansiYellow))
+	}
+	return out
+}
+
+// table draws rows with aligned columns; the first row is the header
+func (s styler) table(rows [][]string) []string {
+	cells := make([][]string, len(rows))
+	var widths []int
+	for r, row := range rows {
+		cells[r] = make([]string, len(row))
+		for c, cell := range row {
+			cells[r][c] = s.inline(cell)
+			if c >= len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[c] = max(widths[c], visibleWidth(cells[r][c]))
+		}
+	}
+
+	sep := " | "
+	if s.ansi {
+		sep = s.style(" │ ", ansiDim)
+	}
+	var out []string
+	for r, row := range cells {
+		parts := make([]string, len(widths))
+		for c := range widths {
+			var cell string
+			if c < len(row) {
+				cell = row[c]
+			}
+			if r == 0 {
+				cell = s.style(cell, ansiBold)
+			}
+			if c < len(widths)-1 {
+				cell += strings.Repeat(" ", widths[c]-visibleWidth(cell))
+			}
+			parts[c] = cell
+		}
+		out = append(out, strings.TrimRight(strings.Join(parts, sep), " "))
+
+		if r == 0 {
+			lines := make([]string, len(widths))
+			for c, w := range widths {
+				lines[c] = strings.Repeat("─", w)
+			}
+			joint := "─┼─"
+			if !s.ansi {
+				joint = "-+-"
+				for c, w := range widths {
+					lines[c] = strings.Repeat("-", w)
+				}
+			}
+			out = append(out, s.style(strings.Join(lines, joint), ansiDim))
+		}
+	}
+	return out
+}
+
+// inline styles emphasis, links and code spans. Text inside backticks is
+// left untouched.
+func (s styler) inline(text string) string {
+	parts := strings.Split(text, "`")
+	if len(parts)%2 == 0 {
+		// Unbalanced backtick: treat the last one literally
+		parts[len(parts)-2] += "`" + parts[len(parts)-1]
+		parts = parts[:len(parts)-1]
+	}
+
+	var sb strings.Builder
+	for i, part := range parts {
+		if i%2 == 1 {
+			sb.WriteString(s.style(part, ansiCyan))
+			continue
+		}
+		sb.WriteString(s.emphasis(part))
+	}
+	return sb.String()
+}
+
+func (s styler) emphasis(text string) string {
+	text = linkPattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := linkPattern.FindStringSubmatch(m)
+		if sub[1] == sub[2] {
+			return s.style(sub[1], ansiUnderline)
+		}
+		return s.style(sub[1], ansiUnderline) + " " + s.style("("+sub[2]+")", ansiDim)
+	})
+	text = boldPattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := boldPattern.FindStringSubmatch(m)
+		return s.style(sub[1]+sub[2], ansiBold)
+	})
+	text = strikePattern.ReplaceAllStringFunc(text, func(m string) string {
+		return s.style(strikePattern.FindStringSubmatch(m)[1], ansiStrike)
+	})
+	return italicPattern.ReplaceAllStringFunc(text, func(m string) string {
+		sub := italicPattern.FindStringSubmatch(m)
+		if sub[1] != "" {
+			return s.style(sub[1], ansiItalic)
+		}
+		return sub[2] + s.style(sub[3], ansiItalic) + sub[4]
+	})
+}
+
+func isTableRow(line string) bool {
+	t := strings.TrimSpace(line)
+	return strings.HasPrefix(t, "|") || (strings.Count(t, "|") >= 1 && !strings.HasPrefix(t, "`"))
+}
+
+func splitRow(line string) []string {
+	t := strings.TrimSpace(line)
+	t = strings.TrimPrefix(t, "|")
+	t = strings.TrimSuffix(t, "|")
+	cells := strings.Split(t, "|")
+	for i, c := range cells {
+		cells[i] = strings.TrimSpace(c)
+	}
+	return cells
+}
+
+// ansiPattern matches SGR escape sequences
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// visibleWidth counts the runes a cell shows on screen
+func visibleWidth(text string) int {
+	return utf8.RuneCountInString(ansiPattern.ReplaceAllString(text, ""))
+}
@@ -0,0 +1,63 @@
+package render
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+// Output formats, matching ResponseFormat.Type
+const (
+	FormatMarkdown = "markdown" // Styled on a terminal, raw when piped
+	FormatText     = "text"     // Markdown syntax stripped
+	FormatRaw      = "raw"      // Printed as generated
+)
+
+// Write prints text to w in the given format. Markdown is only styled
+// when w is a terminal and NO_COLOR is unset, so piped output stays
+// machine-readable. Unknown formats are printed raw.
+func Write(w io.Writer, text, format string) error {
+	var out string
+	switch format {
+	case FormatMarkdown:
+		if IsTerminal(w) && os.Getenv("NO_COLOR") == "" {
+			out = Markdown(text)
+		} else {
+			out = text
+		}
+	case FormatText:
+		out = StripMarkdown(text)
+	default:
+		out = text
+	}
+	if !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	_, err := io.WriteString(w, out)
+	return err
+}
+
+// IsTerminal reports whether w is a character device such as a TTY
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Markdown renders Markdown with ANSI styles for a terminal: headings,
+// emphasis, inline code, links, lists, quotes, rules, fenced code blocks
+// and tables
+func Markdown(text string) string {
+	return render(text, true)
+}
+
+// StripMarkdown renders Markdown as plain text, keeping its layout
+func StripMarkdown(text string) string {
+	return render(text, false)
+}
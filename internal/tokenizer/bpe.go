@@ -0,0 +1,22 @@
+package tokenizer
+
+// bpeMerge splits one pre-tokenized piece into parts and repeatedly merges
+// the adjacent pair with the lowest rank until no ranked pair is left.
+// rank returns false for pairs that never merge.
+func bpeMerge(parts []string, rank func(a, b string) (int, bool)) []string {
+	for len(parts) > 1 {
+		best, bestRank := -1, 0
+		for i := 0; i < len(parts)-1; i++ {
+			r, ok := rank(parts[i], parts[i+1])
+			if ok && (best < 0 || r < bestRank) {
+				best, bestRank = i, r
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts[best] += parts[best+1]
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+	return parts
+}
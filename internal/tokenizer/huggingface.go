@@ -0,0 +1,255 @@
+package tokenizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// metaspace is the SentencePiece word-boundary marker that replaces spaces
+const metaspace = "▁"
+
+// HuggingFace is a BPE tokenizer loaded from a Hugging Face tokenizer.json.
+// It supports the two layouts used by current open models: SentencePiece
+// style (Metaspace with byte fallback, e.g. Mistral and Llama 2) and
+// byte-level BPE (e.g. Llama 3). Added special tokens are encoded as plain
+// text.
+type HuggingFace struct {
+	vocab        map[string]int
+	merges       map[string]int // "left right" -> rank
+	byteLevel    bool
+	prependSpace bool
+	byteFallback bool
+	ignoreMerges bool
+	unk          int // -1 when the model has no unknown token
+}
+
+// hfFile is the subset of tokenizer.json that we read
+type hfFile struct {
+	Normalizer   json.RawMessage `json:"normalizer"`
+	PreTokenizer json.RawMessage `json:"pre_tokenizer"`
+	Model        struct {
+		Type         string          `json:"type"`
+		Vocab        map[string]int  `json:"vocab"`
+		Merges       json.RawMessage `json:"merges"`
+		UnkToken     *string         `json:"unk_token"`
+		ByteFallback bool            `json:"byte_fallback"`
+		IgnoreMerges bool            `json:"ignore_merges"`
+	} `json:"model"`
+}
+
+// LoadHuggingFace reads a BPE tokenizer.json
+func LoadHuggingFace(path string) (*HuggingFace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tokenizer.json: %w", err)
+	}
+
+	var file hfFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse tokenizer.json: %w", err)
+	}
+	if file.Model.Type != "BPE" {
+		return nil, fmt.Errorf("unsupported tokenizer model type %q", file.Model.Type)
+	}
+
+	merges, err := parseMerges(file.Model.Merges)
+	if err != nil {
+		return nil, err
+	}
+
+	normalizer := decodeComponent(file.Normalizer)
+	preTokenizer := decodeComponent(file.PreTokenizer)
+
+	hf := &HuggingFace{
+		vocab:        file.Model.Vocab,
+		merges:       merges,
+		byteFallback: file.Model.ByteFallback,
+		ignoreMerges: file.Model.IgnoreMerges,
+		unk:          -1,
+	}
+	if file.Model.UnkToken != nil {
+		if id, ok := hf.vocab[*file.Model.UnkToken]; ok {
+			hf.unk = id
+		}
+	}
+
+	switch {
+	case findComponent(preTokenizer, "ByteLevel") != nil:
+		hf.byteLevel = true
+	case findComponent(normalizer, "Prepend") != nil:
+		hf.prependSpace = true
+	case findComponent(preTokenizer, "Metaspace") != nil:
+		m := findComponent(preTokenizer, "Metaspace")
+		scheme, _ := m["prepend_scheme"].(string)
+		addPrefix, hasAddPrefix := m["add_prefix_space"].(bool)
+		hf.prependSpace = scheme != "never" && (!hasAddPrefix || addPrefix)
+	}
+	return hf, nil
+}
+
+// parseMerges accepts both the legacy ["a b", ...] and the newer
+// [["a", "b"], ...] merge formats
+func parseMerges(raw json.RawMessage) (map[string]int, error) {
+	merges := make(map[string]int)
+	if len(raw) == 0 {
+		return merges, nil
+	}
+
+	var legacy []string
+	if err := json.Unmarshal(raw, &legacy); err == nil {
+		for rank, m := range legacy {
+			merges[m] = rank
+		}
+		return merges, nil
+	}
+
+	var pairs [][2]string
+	if err := json.Unmarshal(raw, &pairs); err != nil {
+		return nil, fmt.Errorf("failed to parse tokenizer merges: %w", err)
+	}
+	for rank, p := range pairs {
+		merges[p[0]+" "+p[1]] = rank
+	}
+	return merges, nil
+}
+
+func decodeComponent(raw json.RawMessage) interface{} {
+	var v interface{}
+	if len(raw) > 0 {
+		_ = json.Unmarshal(raw, &v)
+	}
+	return v
+}
+
+// findComponent searches a normalizer or pre-tokenizer definition,
+// including nested Sequences, for a component of the given type
+func findComponent(v interface{}, typ string) map[string]interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		if v["type"] == typ {
+			return v
+		}
+		for _, child := range v {
+			if found := findComponent(child, typ); found != nil {
+				return found
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if found := findComponent(child, typ); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+func (hf *HuggingFace) Encode(text string) []int {
+	if text == "" {
+		return nil
+	}
+
+	var ids []int
+	for _, word := range hf.words(text) {
+		if id, ok := hf.vocab[word]; ok && hf.ignoreMerges {
+			ids = append(ids, id)
+			continue
+		}
+
+		var parts []string
+		for _, r := range word {
+			parts = append(parts, string(r))
+		}
+		parts = bpeMerge(parts, func(a, b string) (int, bool) {
+			r, ok := hf.merges[a+" "+b]
+			return r, ok
+		})
+		for _, p := range parts {
+			ids = hf.appendPart(ids, p)
+		}
+	}
+	return ids
+}
+
+func (hf *HuggingFace) Count(text string) int {
+	return len(hf.Encode(text))
+}
+
+// words normalizes and pre-tokenizes text into the pieces BPE runs over
+func (hf *HuggingFace) words(text string) []string {
+	if hf.byteLevel {
+		pieces := splitCL100K(text)
+		for i, p := range pieces {
+			pieces[i] = byteLevelEncode(p)
+		}
+		return pieces
+	}
+
+	text = strings.ReplaceAll(text, " ", metaspace)
+	if hf.prependSpace && !strings.HasPrefix(text, metaspace) {
+		text = metaspace + text
+	}
+
+	// Split before each word boundary marker that follows other text, so
+	// runs of spaces stay with the word after them
+	var words []string
+	start := 0
+	prevMarker := true
+	for i, r := range text {
+		marker := string(r) == metaspace
+		if marker && !prevMarker {
+			words = append(words, text[start:i])
+			start = i
+		}
+		prevMarker = marker
+	}
+	return append(words, text[start:])
+}
+
+// appendPart maps one merged part to its ID, falling back to <0xXX> byte
+// tokens and then to the unknown token
+func (hf *HuggingFace) appendPart(ids []int, part string) []int {
+	if id, ok := hf.vocab[part]; ok {
+		return append(ids, id)
+	}
+	if hf.byteFallback {
+		for i := 0; i < len(part); i++ {
+			if id, ok := hf.vocab[fmt.Sprintf("<0x%02X>", part[i])]; ok {
+				ids = append(ids, id)
+			} else if hf.unk >= 0 {
+				ids = append(ids, hf.unk)
+			}
+		}
+		return ids
+	}
+	if hf.unk >= 0 {
+		return append(ids, hf.unk)
+	}
+	return ids
+}
+
+// byteToRune is GPT-2's reversible byte-to-unicode table: printable bytes
+// map to themselves and the rest are shifted past U+0100
+var byteToRune = func() [256]rune {
+	var table [256]rune
+	n := 0
+	for b := 0; b < 256; b++ {
+		if (b >= '!' && b <= '~') || (b >= 0xA1 && b <= 0xAC) || (b >= 0xAE && b <= 0xFF) {
+			table[b] = rune(b)
+		} else {
+			table[b] = rune(256 + n)
+			n++
+		}
+	}
+	return table
+}()
+
+func byteLevelEncode(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		sb.WriteRune(byteToRune[s[i]])
+	}
+	return sb.String()
+}
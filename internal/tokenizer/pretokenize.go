@@ -0,0 +1,204 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+)
+
+// The tiktoken split patterns rely on lookahead (`\s+(?!\S)`) and
+// possessive quantifiers, neither of which Go's regexp supports, so the
+// pre-tokenizers below walk the text by hand following the same
+// alternatives in the same order.
+
+// splitCL100K splits text like cl100k_base's pattern:
+//
+//	'(?i:[sdmt]|ll|ve|re)|[^\r\n\p{L}\p{N}]?+\p{L}+|\p{N}{1,3}|
+//	 ?[^\s\p{L}\p{N}]++[\r\n]*|\s*[\r\n]|\s+(?!\S)|\s+
+func splitCL100K(text string) []string {
+	r := []rune(text)
+	var pieces []string
+	for i := 0; i < len(r); {
+		n := matchContraction(r, i)
+		if n == 0 {
+			n = matchLetters(r, i)
+		}
+		if n == 0 {
+			n = matchDigits(r, i)
+		}
+		if n == 0 {
+			n = matchPunctuation(r, i, false)
+		}
+		if n == 0 {
+			n = matchWhitespace(r, i)
+		}
+		if n == 0 {
+			n = 1
+		}
+		pieces = append(pieces, string(r[i:i+n]))
+		i += n
+	}
+	return pieces
+}
+
+// splitO200K splits text like o200k_base's pattern, which additionally
+// breaks words at lower-to-upper case changes and keeps contractions
+// attached to the word before them:
+//
+//	[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?|
+//	[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?|
+//	\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+(?!\S)|\s+
+func splitO200K(text string) []string {
+	r := []rune(text)
+	var pieces []string
+	for i := 0; i < len(r); {
+		n := matchCasedWord(r, i)
+		if n == 0 {
+			n = matchDigits(r, i)
+		}
+		if n == 0 {
+			n = matchPunctuation(r, i, true)
+		}
+		if n == 0 {
+			n = matchWhitespace(r, i)
+		}
+		if n == 0 {
+			n = 1
+		}
+		pieces = append(pieces, string(r[i:i+n]))
+		i += n
+	}
+	return pieces
+}
+
+func isNewline(c rune) bool { return c == '\r' || c == '\n' }
+
+func isLetterOrNumber(c rune) bool { return unicode.IsLetter(c) || unicode.IsNumber(c) }
+
+// isPrefix reports whether c may lead a word: [^\r\n\p{L}\p{N}]
+func isPrefix(c rune) bool { return !isNewline(c) && !isLetterOrNumber(c) }
+
+// matchContraction matches '(?i:[sdmt]|ll|ve|re)
+func matchContraction(r []rune, i int) int {
+	if r[i] != '\'' || i+1 >= len(r) {
+		return 0
+	}
+	if i+2 < len(r) {
+		switch strings.ToLower(string(r[i+1 : i+3])) {
+		case "ll", "ve", "re":
+			return 3
+		}
+	}
+	switch unicode.ToLower(r[i+1]) {
+	case 's', 'd', 'm', 't':
+		return 2
+	}
+	return 0
+}
+
+// matchLetters matches [^\r\n\p{L}\p{N}]?\p{L}+
+func matchLetters(r []rune, i int) int {
+	j := i
+	if isPrefix(r[j]) && j+1 < len(r) && unicode.IsLetter(r[j+1]) {
+		j++
+	}
+	start := j
+	for j < len(r) && unicode.IsLetter(r[j]) {
+		j++
+	}
+	if j == start {
+		return 0
+	}
+	return j - i
+}
+
+func isUpperClass(c rune) bool {
+	return unicode.In(c, unicode.Lu, unicode.Lt, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+func isLowerClass(c rune) bool {
+	return unicode.In(c, unicode.Ll, unicode.Lm, unicode.Lo, unicode.M)
+}
+
+// matchCasedWord matches o200k's two word alternatives: an optional
+// prefix, a run of upper-case letters, a run of lower-case letters and an
+// optional contraction suffix
+func matchCasedWord(r []rune, i int) int {
+	j := i
+	if isPrefix(r[j]) && j+1 < len(r) && (isUpperClass(r[j+1]) || isLowerClass(r[j+1])) {
+		j++
+	}
+	start := j
+	for j < len(r) && isUpperClass(r[j]) {
+		j++
+	}
+	for j < len(r) && isLowerClass(r[j]) {
+		j++
+	}
+	if j == start {
+		return 0
+	}
+	return j - i + matchSuffixContraction(r, j)
+}
+
+// matchSuffixContraction matches (?i:'s|'t|'re|'ve|'m|'ll|'d)
+func matchSuffixContraction(r []rune, i int) int {
+	if i >= len(r) || r[i] != '\'' {
+		return 0
+	}
+	return matchContraction(r, i)
+}
+
+// matchDigits matches \p{N}{1,3}
+func matchDigits(r []rune, i int) int {
+	j := i
+	for j < len(r) && j-i < 3 && unicode.IsNumber(r[j]) {
+		j++
+	}
+	return j - i
+}
+
+// matchPunctuation matches ` ?[^\s\p{L}\p{N}]+[\r\n]*`, with o200k also
+// absorbing trailing slashes
+func matchPunctuation(r []rune, i int, slashes bool) int {
+	j := i
+	if r[j] == ' ' {
+		j++
+	}
+	start := j
+	for j < len(r) && !unicode.IsSpace(r[j]) && !isLetterOrNumber(r[j]) {
+		j++
+	}
+	if j == start {
+		return 0
+	}
+	for j < len(r) && (isNewline(r[j]) || (slashes && r[j] == '/')) {
+		j++
+	}
+	return j - i
+}
+
+// matchWhitespace covers the three whitespace alternatives:
+//
+//	\s*[\r\n]+   up to the last newline in the run
+//	\s+(?!\S)    the run minus its last character, so a single space stays
+//	             attached to the following word
+//	\s+          otherwise the whole run
+func matchWhitespace(r []rune, i int) int {
+	j := i
+	lastNewline := -1
+	for j < len(r) && unicode.IsSpace(r[j]) {
+		if isNewline(r[j]) {
+			lastNewline = j
+		}
+		j++
+	}
+	switch {
+	case j == i:
+		return 0
+	case lastNewline >= 0:
+		return lastNewline + 1 - i
+	case j == len(r) || j-i == 1:
+		return j - i
+	}
+	return j - i - 1
+}
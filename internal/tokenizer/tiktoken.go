@@ -0,0 +1,105 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// Tiktoken is a byte-level BPE encoder compatible with OpenAI's tiktoken.
+// Special tokens such as <|endoftext|> are encoded as plain text.
+type Tiktoken struct {
+	ranks map[string]int
+	split func(string) []string
+}
+
+// LoadTiktoken reads a .tiktoken rank file (one "<base64 token> <rank>"
+// per line) for the cl100k_base or o200k_base encoding
+func LoadTiktoken(path, encoding string) (*Tiktoken, error) {
+	var split func(string) []string
+	switch encoding {
+	case "cl100k_base":
+		split = splitCL100K
+	case "o200k_base":
+		split = splitO200K
+	default:
+		return nil, fmt.Errorf("unsupported tiktoken encoding %q", encoding)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tiktoken file: %w", err)
+	}
+
+	ranks := make(map[string]int, 200000)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		fields := bytes.Fields(scanner.Bytes())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected \"<token> <rank>\"", path, line)
+		}
+		token, err := base64.StdEncoding.DecodeString(string(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid token: %w", path, line, err)
+		}
+		rank, err := strconv.Atoi(string(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid rank: %w", path, line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to parse tiktoken file: %w", err)
+	}
+
+	// Encode relies on every single byte having a rank, so that BPE never
+	// ends on a piece it can't map to an ID
+	for b := 0; b < 256; b++ {
+		if _, ok := ranks[string([]byte{byte(b)})]; !ok {
+			return nil, fmt.Errorf("%s: no rank for byte 0x%02x", path, b)
+		}
+	}
+
+	return &Tiktoken{ranks: ranks, split: split}, nil
+}
+
+func (t *Tiktoken) Encode(text string) []int {
+	var ids []int
+	for _, piece := range t.split(text) {
+		if id, ok := t.ranks[piece]; ok {
+			ids = append(ids, id)
+			continue
+		}
+
+		parts := make([]string, len(piece))
+		for i := 0; i < len(piece); i++ {
+			parts[i] = piece[i : i+1]
+		}
+		parts = bpeMerge(parts, func(a, b string) (int, bool) {
+			r, ok := t.ranks[a+b]
+			return r, ok
+		})
+		for _, p := range parts {
+			if id, ok := t.ranks[p]; ok {
+				ids = append(ids, id)
+				continue
+			}
+			// Unreachable for files accepted by LoadTiktoken; encode the
+			// piece byte by byte rather than emitting a bogus ID
+			for i := 0; i < len(p); i++ {
+				ids = append(ids, t.ranks[p[i:i+1]])
+			}
+		}
+	}
+	return ids
+}
+
+func (t *Tiktoken) Count(text string) int {
+	return len(t.Encode(text))
+}
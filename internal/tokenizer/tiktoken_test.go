@@ -0,0 +1,49 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writeRanks writes a .tiktoken file ranking every byte except skip
+// (-1 for none) followed by the extra tokens
+func writeRanks(t *testing.T, skip int, extra ...string) string {
+	var sb strings.Builder
+	rank := 0
+	for b := 0; b < 256; b++ {
+		if b != skip {
+			fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(b)}), rank)
+		}
+		rank++
+	}
+	for _, token := range extra {
+		fmt.Fprintf(&sb, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(token)), rank)
+		rank++
+	}
+	path := filepath.Join(t.TempDir(), "cl100k_base.tiktoken")
+	if err := os.WriteFile(path, []byte(sb.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTiktokenEncode(t *testing.T) {
+	tok, err := LoadTiktoken(writeRanks(t, -1, "ab", "abc"), "cl100k_base")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := tok.Encode("abcd"), []int{257, 'd'}; !slices.Equal(got, want) {
+		t.Errorf("Encode = %v, want %v", got, want)
+	}
+}
+
+func TestTiktokenMissingByteRank(t *testing.T) {
+	if _, err := LoadTiktoken(writeRanks(t, 'x'), "cl100k_base"); err == nil {
+		t.Error("file without a rank for 'x' accepted")
+	}
+}
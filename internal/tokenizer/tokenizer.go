@@ -0,0 +1,107 @@
+package tokenizer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Tokenizer converts text into model token IDs
+type Tokenizer interface {
+	// Encode returns the token IDs for text
+	Encode(text string) []int
+
+	// Count returns len(Encode(text)) without keeping the IDs
+	Count(text string) int
+}
+
+// DirEnv names the environment variable holding the tokenizer directory.
+// It should contain tiktoken files (cl100k_base.tiktoken,
+// o200k_base.tiktoken) and Hugging Face tokenizer.json files, either as
+// <model>/tokenizer.json or <model>.json.
+const DirEnv = "RAG_TOKENIZER_DIR"
+
+// defaultDir is used when DirEnv is unset
+const defaultDir = "tokenizers"
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]Tokenizer{}
+)
+
+// ForModel loads the tokenizer matching a model name from the tokenizer
+// directory. OpenAI models map to their tiktoken encoding; anything else
+// (e.g. "mistral:7b", "llama3") is looked up as a tokenizer.json by its
+// base name. Loaded tokenizers are cached per file.
+func ForModel(model string) (Tokenizer, error) {
+	dir := os.Getenv(DirEnv)
+	if dir == "" {
+		dir = defaultDir
+	}
+
+	if encoding := openAIEncoding(model); encoding != "" {
+		return cached(filepath.Join(dir, encoding+".tiktoken"), func(path string) (Tokenizer, error) {
+			return LoadTiktoken(path, encoding)
+		})
+	}
+
+	name := strings.ToLower(model)
+	if i := strings.IndexByte(name, ':'); i >= 0 {
+		name = name[:i] // Ollama tags like "mistral:7b-instruct"
+	}
+	name = filepath.Base(name)
+	if name == "" || name == "." {
+		return nil, fmt.Errorf("no tokenizer for empty model name")
+	}
+
+	for _, path := range []string{
+		filepath.Join(dir, name, "tokenizer.json"),
+		filepath.Join(dir, name+".json"),
+	} {
+		if _, err := os.Stat(path); err == nil {
+			return cached(path, func(path string) (Tokenizer, error) {
+				return LoadHuggingFace(path)
+			})
+		}
+	}
+	return nil, fmt.Errorf("no tokenizer found for model %q in %s", model, dir)
+}
+
+// openAIEncoding returns the tiktoken encoding used by an OpenAI model, or
+// "" for models from other providers
+func openAIEncoding(model string) string {
+	m := strings.ToLower(model)
+	switch {
+	case strings.HasPrefix(m, "gpt-4o"), strings.HasPrefix(m, "gpt-4.1"), strings.HasPrefix(m, "gpt-4.5"),
+		strings.HasPrefix(m, "gpt-5"), strings.HasPrefix(m, "o1"), strings.HasPrefix(m, "o3"),
+		strings.HasPrefix(m, "o4"), strings.HasPrefix(m, "chatgpt-4o"):
+		return "o200k_base"
+	case strings.HasPrefix(m, "gpt-4"), strings.HasPrefix(m, "gpt-3.5"), strings.HasPrefix(m, "gpt-35"),
+		strings.HasPrefix(m, "text-embedding-3"), strings.HasPrefix(m, "text-embedding-ada-002"):
+		return "cl100k_base"
+	}
+	return ""
+}
+
+func cached(path string, load func(string) (Tokenizer, error)) (Tokenizer, error) {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+
+	if tok, ok := cache[path]; ok {
+		return tok, nil
+	}
+	tok, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	cache[path] = tok
+	return tok, nil
+}
+
+// EstimateCount is the fallback used when no tokenizer file is available:
+// roughly four characters per token for English text
+func EstimateCount(text string) int {
+	return len([]rune(text)) / 4
+}
@@ -4,8 +4,10 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"os"
 	"ragframework/internal/embedder"
 	"ragframework/internal/llm"
+	"ragframework/internal/render"
 	"ragframework/internal/retriever"
 	"ragframework/scripts"
 
@@ -21,6 +23,7 @@ func main() {
 	llmProvider := flag.String("llm", "openai", "LLM provider to use: 'openai', etc.")
 	host := flag.String("host", "localhost:6333", "Qdrant host (ignored for Weaviate)")
 	collection := flag.String("collection", "documents", "Collection name for Qdrant")
+	format := flag.String("format", "markdown", "Answer format: 'markdown' (styled on a terminal), 'text' or 'raw'")
 
 	flag.Parse()
 
@@ -100,7 +103,10 @@ func main() {
 			log.Fatalf("❌ LLM generation failed: %v", err)
 		}
 
-		fmt.Println("📣 Final Answer:\n", response)
+		fmt.Println("📣 Final Answer:")
+		if err := render.Write(os.Stdout, response, *format); err != nil {
+			log.Fatalf("❌ Failed to print answer: %v", err)
+		}
 	}
 }
 
@@ -5,20 +5,28 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"io"
 	"log"
+	"math"
 	"math/rand"
 	"net/http"
 
+	"ragframework/internal/chunker"
 	"ragframework/internal/embedder"
+	"ragframework/internal/rag"
 	"ragframework/internal/reader"
 
 	"github.com/weaviate/weaviate-go-client/v4/weaviate"
 	"github.com/weaviate/weaviate/entities/models"
 )
 
-// CreateSchema creates Weaviate schema (not needed for Qdrant)
+// CreateSchema creates Weaviate schema (not needed for Qdrant).
+// The chunk properties are declared up front so "chunk_index" is an int,
+// which the retriever's window filters require, and so ids and parent
+// text stay out of keyword search.
 func CreateSchema(client *weaviate.Client) {
+	notSearchable := false
 	class := &models.Class{
 		Class: "Document",
 		Properties: []*models.Property{
@@ -26,6 +34,27 @@ func CreateSchema(client *weaviate.Client) {
 				Name:     "text",
 				DataType: []string{"text"},
 			},
+			{
+				Name:            rag.MetaDocID,
+				DataType:        []string{"text"},
+				Tokenization:    models.PropertyTokenizationField,
+				IndexSearchable: &notSearchable,
+			},
+			{
+				Name:     rag.MetaChunkIndex,
+				DataType: []string{"int"},
+			},
+			{
+				Name:            rag.MetaParentID,
+				DataType:        []string{"text"},
+				Tokenization:    models.PropertyTokenizationField,
+				IndexSearchable: &notSearchable,
+			},
+			{
+				Name:            rag.MetaParentText,
+				DataType:        []string{"text"},
+				IndexSearchable: &notSearchable,
+			},
 		},
 		Vectorizer: "none",
 	}
@@ -171,3 +200,215 @@ func convertToFloat64(input []float32) []float64 {
 	}
 	return output
 }
+
+// Named vectors used by hybrid Qdrant collections; QdrantRetriever must be
+// configured with the same VectorName and SparseVectorName.
+const (
+	QdrantDenseVector  = "dense"
+	QdrantSparseVector = "text"
+)
+
+// HybridPoint carries both a dense and a sparse vector under their names
+type HybridPoint struct {
+	ID      int                    `json:"id"`
+	Vector  map[string]interface{} `json:"vector"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+// CreateHybridQdrantCollection creates a collection with a named dense
+// vector and an IDF-weighted sparse vector for keyword matching.
+func CreateHybridQdrantCollection(host, collection string, dim int) error {
+	url := fmt.Sprintf("http://%s/collections/%s", host, collection)
+
+	reqBody := map[string]interface{}{
+		"vectors": map[string]interface{}{
+			QdrantDenseVector: map[string]interface{}{
+				"size":     dim,
+				"distance": "Cosine",
+			},
+		},
+		"sparse_vectors": map[string]interface{}{
+			QdrantSparseVector: map[string]interface{}{
+				"modifier": "idf",
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(reqBody); err != nil {
+		return fmt.Errorf("❌ Failed to encode collection request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, url, &buf)
+	if err != nil {
+		return fmt.Errorf("❌ Failed to create collection request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("❌ Collection request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("❌ Collection creation failed. Status: %d, Body: %s", resp.StatusCode, string(body))
+	}
+
+	log.Println("✅ Hybrid collection created:", collection)
+	return nil
+}
+
+// UploadHybridTextToQdrant uploads text with its dense embedding and a
+// sparse keyword vector to a collection made by CreateHybridQdrantCollection.
+func UploadHybridTextToQdrant(host, collection string, id int, text string, vector []float32) error {
+	url := fmt.Sprintf("http://%s/collections/%s/points?wait=true", host, collection)
+
+	point := HybridPoint{
+		ID: id,
+		Vector: map[string]interface{}{
+			QdrantDenseVector:  vector,
+			QdrantSparseVector: embedder.SparseEmbed(text),
+		},
+		Payload: map[string]interface{}{"text": text},
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]interface{}{"points": []HybridPoint{point}}); err != nil {
+		return fmt.Errorf("❌ Failed to encode upload request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, url, &buf)
+	if err != nil {
+		return fmt.Errorf("❌ Failed to create upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("❌ Upload request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("❌ Upload failed. Status: %d, Body: %s", resp.StatusCode, string(body))
+	}
+
+	fmt.Println("📄 Uploaded document to Qdrant (hybrid):", text)
+	return nil
+}
+
+// UploadChunks embeds chunks and uploads them with their metadata stored
+// alongside the text (Qdrant payload or Weaviate properties), so retrievers
+// can return it.
+func UploadChunks(dbType, host, collection string, chunks []rag.ContextChunk, wClient *weaviate.Client) error {
+	var points []Point
+	for _, chunk := range chunks {
+		vector, err := embedder.EmbedText(chunk.Text)
+		if err != nil {
+			return fmt.Errorf("❌ Embedding failed: %w", err)
+		}
+
+		properties := map[string]interface{}{"text": chunk.Text}
+		for k, v := range chunk.Metadata {
+			properties[k] = v
+		}
+
+		switch dbType {
+		case "weaviate":
+			_, err = wClient.Data().Creator().
+				WithClassName("Document").
+				WithProperties(properties).
+				WithVector(vector).
+				Do(context.Background())
+			if err != nil {
+				return fmt.Errorf("❌ Failed to upload to Weaviate: %w", err)
+			}
+		case "qdrant":
+			points = append(points, Point{
+				ID:      chunkPointID(chunk),
+				Vector:  vector,
+				Payload: properties,
+			})
+		default:
+			return fmt.Errorf("❌ Unknown DB type: %s", dbType)
+		}
+	}
+
+	if dbType == "qdrant" && len(points) > 0 {
+		var buf bytes.Buffer
+		if err := json.NewEncoder(&buf).Encode(UploadRequest{Points: points}); err != nil {
+			return fmt.Errorf("❌ Failed to encode upload request: %w", err)
+		}
+
+		url := fmt.Sprintf("http://%s/collections/%s/points?wait=true", host, collection)
+		req, err := http.NewRequest(http.MethodPut, url, &buf)
+		if err != nil {
+			return fmt.Errorf("❌ Failed to create upload request: %w", err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("❌ Upload request failed: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			return fmt.Errorf("❌ Upload failed. Status: %d, Body: %s", resp.StatusCode, string(body))
+		}
+	}
+
+	log.Printf("📄 Uploaded %d chunks to %s\n", len(chunks), dbType)
+	return nil
+}
+
+// UploadParentChildDocument splits text into parent sections and small
+// child chunks and uploads only the children for search. Parents go to
+// store when one is given; otherwise each child carries its parent's text
+// in the payload so no separate docstore is needed.
+func UploadParentChildDocument(dbType, host, collection, docID, text string, parentSize, childSize int, store *rag.MemoryDocStore, wClient *weaviate.Client) error {
+	parents, children := chunker.ParentChild(docID, text, parentSize, childSize)
+
+	parentText := make(map[string]string, len(parents))
+	for _, parent := range parents {
+		id := parent.Metadata["id"].(string)
+		if store != nil {
+			store.Put(id, parent)
+		} else {
+			parentText[id] = parent.Text
+		}
+	}
+	if store == nil {
+		for _, child := range children {
+			child.Metadata[rag.MetaParentText] = parentText[child.Metadata[rag.MetaParentID].(string)]
+		}
+	}
+
+	return UploadChunks(dbType, host, collection, children, wClient)
+}
+
+// chunkPointID derives a stable Qdrant point ID from the chunk's document
+// and position, so re-ingesting a document overwrites its old points.
+// Chunks without both fields are keyed by their text instead, so they
+// don't all collide on one ID.
+func chunkPointID(chunk rag.ContextChunk) int {
+	h := fnv.New64a()
+	docID, hasDoc := chunk.Metadata[rag.MetaDocID]
+	index, hasIndex := chunk.Metadata[rag.MetaChunkIndex]
+	if hasDoc && hasIndex && docID != nil && index != nil {
+		fmt.Fprintf(h, "%v/%v", docID, index)
+	} else {
+		fmt.Fprintf(h, "text:%s", chunk.Text)
+	}
+	return int(h.Sum64() & math.MaxInt64)
+}
+
+// UploadChunkedDocument splits text into overlapping chunks tagged with
+// their document ID and position, then uploads them.
+func UploadChunkedDocument(dbType, host, collection, docID, text string, size, overlap int, wClient *weaviate.Client) error {
+	return UploadChunks(dbType, host, collection, chunker.Document(docID, text, size, overlap), wClient)
+}